@@ -6,16 +6,24 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/ipfs/gateway-conformance/tests"
 	"github.com/ipfs/gateway-conformance/tooling"
+	"github.com/ipfs/gateway-conformance/tooling/aggregate"
 	"github.com/ipfs/gateway-conformance/tooling/car"
 	"github.com/ipfs/gateway-conformance/tooling/dnslink"
 	"github.com/ipfs/gateway-conformance/tooling/fixtures"
+	"github.com/ipfs/gateway-conformance/tooling/report"
+	"github.com/ipfs/gateway-conformance/tooling/server"
 	specPresets "github.com/ipfs/gateway-conformance/tooling/specs"
+	"github.com/ipfs/gateway-conformance/tooling/test2json"
 	"github.com/urfave/cli/v2"
 )
 
@@ -35,6 +43,54 @@ func (o out) Write(p []byte) (n int, err error) {
 	return o.Writer.Write(p)
 }
 
+// runSuiteCommand is the hidden command the test command re-executes the
+// binary with to run the test suite.
+const runSuiteCommand = "run-suite"
+
+// goTestFlags lists the `go test` flags users may pass after `--` which have
+// to be prefixed with "test." when given to the test binary.
+var goTestFlags = map[string]bool{
+	"count":    true,
+	"cpu":      true,
+	"failfast": true,
+	"fullpath": true,
+	"list":     true,
+	"parallel": true,
+	"run":      true,
+	"short":    true,
+	"shuffle":  true,
+	"skip":     true,
+	"timeout":  true,
+	"v":        true,
+}
+
+// testFlags converts `go test` style flags (e.g. -run, -timeout) to their
+// test binary equivalents (-test.run, -test.timeout). Other arguments are
+// passed as-is.
+func testFlags(args []string) []string {
+	result := make([]string, 0, len(args))
+	for _, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if strings.HasPrefix(arg, "-") && name != "" {
+			key, _, _ := strings.Cut(name, "=")
+			if goTestFlags[key] {
+				arg = "-test." + name
+			}
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// hasFlag returns true when the flag, e.g. "test.timeout", is set in the args,
+// as -name=value or -name value.
+func hasFlag(args []string, name string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		key, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		return strings.HasPrefix(arg, "-") && key == name
+	})
+}
+
 func copyFiles(inputPaths []string, outputDirectoryPath string) error {
 	err := os.MkdirAll(outputDirectoryPath, 0755)
 	if err != nil {
@@ -70,6 +126,25 @@ func copyFiles(inputPaths []string, outputDirectoryPath string) error {
 	return nil
 }
 
+func writeSyntheticFixtures(outputDirectoryPath string, maxSize int64) error {
+	err := os.MkdirAll(outputDirectoryPath, 0755)
+	if err != nil {
+		return err
+	}
+	for _, fixture := range car.SyntheticFixtures {
+		if fixture.Source.ContentSize() > maxSize {
+			fmt.Printf("skipping %s, larger than %d bytes\n", fixture.Name, maxSize)
+			continue
+		}
+		fmt.Printf("generating %s\n", fixture.Name)
+		err = fixture.WriteCar(filepath.Join(outputDirectoryPath, fixture.Name+".car"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func main() {
 	app := &cli.App{
 		Name:    "gateway-conformance",
@@ -100,6 +175,105 @@ func main() {
 						Usage:   "The path where the JSON test report should be generated.",
 						Value:   "",
 					},
+					&cli.StringFlag{
+						Name:    "junit",
+						Aliases: []string{"xml"},
+						Usage:   "The path where the JUnit XML test report should be generated.",
+						Value:   "",
+					},
+					&cli.StringFlag{
+						Name:  "html",
+						Usage: "The path where the one-page HTML test report should be generated.",
+						Value: "",
+					},
+					&cli.StringFlag{
+						Name:    "markdown",
+						Aliases: []string{"md"},
+						Usage:   "The path where the summary Markdown test report should be generated.",
+						Value:   "",
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "The path where the summary JSON test report (results and metadata per test) should be generated.",
+						Value: "",
+					},
+					&cli.StringFlag{
+						Name:  "baseline",
+						Usage: "The path of a JSON list of known failing tests (see --write-baseline). Only failures missing from the list fail the run, listed tests that pass are reported so the list can be pruned.",
+						Value: "",
+					},
+					&cli.StringFlag{
+						Name:  "write-baseline",
+						Usage: "The path where the JSON list of the tests failing in this run should be written, for use with --baseline.",
+						Value: "",
+					},
+					&cli.StringFlag{
+						Name:  "record",
+						Usage: "The directory where every HTTP request and response (including bodies) should be recorded, as HAR files.",
+						Value: "",
+					},
+					&cli.StringFlag{
+						Name:  "replay",
+						Usage: "A directory recorded with --record. The tests are run against the recorded responses instead of a live gateway.",
+						Value: "",
+					},
+					&cli.StringFlag{
+						Name:  "run",
+						Usage: "Only run the tests whose name matches the regular expression (see go test -run).",
+						Value: "",
+					},
+					&cli.StringFlag{
+						Name:  "skip",
+						Usage: "Skip the tests whose name matches the regular expression (see go test -skip).",
+						Value: "",
+					},
+					&cli.StringSliceFlag{
+						Name:  "group",
+						Usage: "Only run the tests of these groups, e.g. UnixFS,CORS. \"Other\" selects the tests without a group.",
+					},
+					&cli.StringSliceFlag{
+						Name:  "spec-url",
+						Usage: "Only run the tests covering these specs, or their sections, e.g. https://specs.ipfs.tech/http-gateways/path-gateway/#if-none-match-request-header.",
+					},
+					&cli.BoolFlag{
+						Name:  "head",
+						Usage: "Also send every GET test as a HEAD request, expecting the same status and headers and an empty body.",
+						Value: false,
+					},
+					&cli.StringSliceFlag{
+						Name:  "tests",
+						Usage: "Also run the declarative tests of these YAML or JSON files, or of the files of these directories.",
+					},
+					&cli.IntFlag{
+						Name:  "parallel",
+						Usage: "The number of subtests that may run at the same time. The JSON report is written at the end of the run, in the same order as a sequential run.",
+						Value: 1,
+					},
+					&cli.DurationFlag{
+						Name:  "request-timeout",
+						Usage: "The maximum duration of a single request, reading the response body included. Zero means no limit other than --test-timeout.",
+						Value: 0,
+					},
+					&cli.DurationFlag{
+						Name:  "test-timeout",
+						Usage: "The maximum duration of a subtest, all its requests included.",
+						Value: 2 * time.Minute,
+					},
+					&cli.DurationFlag{
+						Name:  "suite-timeout",
+						Usage: "The maximum duration of the whole test suite, the run fails past it. Zero means no limit. A -timeout passed to the test suite after -- takes precedence.",
+						Value: 10 * time.Minute,
+					},
+					&cli.IntFlag{
+						Name:  "retries",
+						Usage: "The number of times a request is retried after a transport error or an unexpected 5xx response. Tests that pass after a retry are reported as flaky.",
+						Value: 0,
+					},
+					&cli.BoolFlag{
+						Name:  "retry-get-only",
+						Usage: "Only retry GET and HEAD requests.",
+						Value: false,
+					},
 					&cli.StringFlag{
 						Name:    "job-url",
 						Aliases: []string{},
@@ -122,9 +296,28 @@ func main() {
 					env := os.Environ()
 					verbose := cctx.Bool("verbose")
 					specs := cctx.String("specs")
+					recordDir := cctx.String("record")
+					replayDir := cctx.String("replay")
+					if recordDir != "" && replayDir != "" {
+						return cli.Exit("⚠️ --record and --replay cannot be used together", 2)
+					}
 
 					// Handle Gateway Endpoint URL
 					gatewayURL := cctx.String("gateway-url")
+					subdomainGatewayURL := cctx.String("subdomain-url")
+					if replayDir != "" {
+						// Replay the requests made to the recorded gateway.
+						rec, err := readRecording(replayDir)
+						if err != nil {
+							return fmt.Errorf("error reading the recording: %w", err)
+						}
+						if gatewayURL == "" {
+							gatewayURL = rec.GatewayURL
+						}
+						if subdomainGatewayURL == "" {
+							subdomainGatewayURL = rec.SubdomainGatewayURL
+						}
+					}
 					if gatewayURL != "" {
 						envGwURL := fmt.Sprintf("GATEWAY_URL=%s", gatewayURL)
 						if verbose {
@@ -136,7 +329,6 @@ func main() {
 					}
 
 					// Handle Subdomain URL
-					subdomainGatewayURL := cctx.String("subdomain-url")
 					if subdomainGatewayURL != "" {
 						// If set, pass to `go test` via env
 						envSubdomainGwURL := fmt.Sprintf("SUBDOMAIN_GATEWAY_URL=%s", subdomainGatewayURL)
@@ -151,61 +343,122 @@ func main() {
 					}
 
 					// Set other parameters
-					args := []string{"test", "./tests", "-test.v=test2json"}
+					args := []string{"-test.v=test2json"}
 					if specs != "" {
 						args = append(args, fmt.Sprintf("-specs=%s", specs))
 					}
+					if jobURL := cctx.String("job-url"); jobURL != "" {
+						args = append(args, fmt.Sprintf("-job-url=%s", jobURL))
+					}
+
+					if run := cctx.String("run"); run != "" {
+						args = append(args, fmt.Sprintf("-test.run=%s", run))
+					}
+					if skip := cctx.String("skip"); skip != "" {
+						args = append(args, fmt.Sprintf("-test.skip=%s", skip))
+					}
+					if groups := cctx.StringSlice("group"); len(groups) > 0 {
+						args = append(args, fmt.Sprintf("-group=%s", strings.Join(groups, ",")))
+					}
+					if specURLs := cctx.StringSlice("spec-url"); len(specURLs) > 0 {
+						args = append(args, fmt.Sprintf("-spec-url=%s", strings.Join(specURLs, ",")))
+					}
+					if cctx.Bool("head") {
+						args = append(args, "-head")
+					}
+					if tests := cctx.StringSlice("tests"); len(tests) > 0 {
+						args = append(args, fmt.Sprintf("-tests=%s", strings.Join(tests, ",")))
+					}
+
+					parallel := cctx.Int("parallel")
+					if parallel < 1 {
+						return cli.Exit("⚠️ --parallel must be at least 1", 2)
+					}
+					if parallel > 1 {
+						args = append(args, fmt.Sprintf("-concurrency=%d", parallel))
+					}
+					args = append(args, fmt.Sprintf("-test-timeout=%s", cctx.Duration("test-timeout")))
+					if requestTimeout := cctx.Duration("request-timeout"); requestTimeout > 0 {
+						args = append(args, fmt.Sprintf("-request-timeout=%s", requestTimeout))
+					}
+					if retries := cctx.Int("retries"); retries > 0 {
+						args = append(args, fmt.Sprintf("-retries=%d", retries))
+						if cctx.Bool("retry-get-only") {
+							args = append(args, "-retry-get-only")
+						}
+					}
+					if recordDir != "" {
+						if err := writeRecording(recordDir, recording{GatewayURL: gatewayURL, SubdomainGatewayURL: subdomainGatewayURL}); err != nil {
+							return err
+						}
+						args = append(args, fmt.Sprintf("-record=%s", recordDir))
+					}
+					if replayDir != "" {
+						args = append(args, fmt.Sprintf("-replay=%s", replayDir))
+					}
 
-					ldFlag := fmt.Sprintf("-ldflags=-X github.com/ipfs/gateway-conformance/tooling.Version=%s -X github.com/ipfs/gateway-conformance/tooling.JobURL=%s", tooling.Version, cctx.String("job-url"))
-					args = append(args, ldFlag)
+					suiteArgs := testFlags(cctx.Args().Slice())
+					if !hasFlag(suiteArgs, "test.timeout") {
+						args = append(args, fmt.Sprintf("-test.timeout=%s", cctx.Duration("suite-timeout")))
+					}
+					args = append(args, suiteArgs...)
 
-					args = append(args, cctx.Args().Slice()...)
+					executable, err := os.Executable()
+					if err != nil {
+						return err
+					}
 
-					fmt.Println("go " + strings.Join(args, " "))
+					fmt.Println(filepath.Base(executable) + " " + runSuiteCommand + " " + strings.Join(args, " "))
 
 					// Set up streaming JSON pipeline if requested.
-					// go test → MultiWriter(buffer, pipe) → test2json → transformWriter → file
+					// test suite → MultiWriter(buffer, test2json) → transformWriter → file (+ events for the reports)
 					jsonOutput := cctx.String("json-output")
+					reports := reportOutputs{
+						junit:    cctx.String("junit"),
+						html:     cctx.String("html"),
+						markdown: cctx.String("markdown"),
+						json:     cctx.String("report"),
+					}
+					baselinePath := cctx.String("baseline")
+					writeBaselinePath := cctx.String("write-baseline")
+					collectEvents := reports.any() || baselinePath != "" || writeBaselinePath != ""
 
-					var (
-						pipeWriter *io.PipeWriter
-						test2json  *exec.Cmd
-						jsonFile   *os.File
-					)
+					var converter *test2json.Converter
+					var jsonWriters []io.Writer
+					events := &bytes.Buffer{}
 
 					if jsonOutput != "" {
-						if err := os.MkdirAll(filepath.Dir(jsonOutput), 0755); err != nil {
-							return err
-						}
-						var err error
-						jsonFile, err = os.Create(jsonOutput)
+						jsonFile, err := createOutput(jsonOutput)
 						if err != nil {
 							return err
 						}
 						defer jsonFile.Close()
-
-						pr, pw := io.Pipe()
-						pipeWriter = pw
-
-						test2json = exec.Command("go", "tool", "test2json", "-p", "Gateway Tests", "-t")
-						test2json.Env = env
-						test2json.Stdin = pr
-						test2json.Stdout = &transformWriter{w: jsonFile}
-						test2json.Stderr = os.Stderr
-						if err := test2json.Start(); err != nil {
-							return err
+						jsonWriters = append(jsonWriters, jsonFile)
+					}
+					if collectEvents {
+						jsonWriters = append(jsonWriters, events)
+					}
+					// Parallel subtests interleave their events, they are
+					// buffered and sorted once the run is over.
+					unsorted := &bytes.Buffer{}
+					if len(jsonWriters) > 0 {
+						var w io.Writer = io.MultiWriter(jsonWriters...)
+						if parallel > 1 {
+							w = unsorted
 						}
+						converter = test2json.NewConverter(&transformWriter{w: w}, "Gateway Tests", test2json.Timestamp)
 					}
 
 					// Execute tests against URLs
 					output := &bytes.Buffer{}
 					testWriter := io.Writer(output)
-					if pipeWriter != nil {
-						testWriter = io.MultiWriter(output, pipeWriter)
+					if converter != nil {
+						testWriter = io.MultiWriter(output, converter)
 					}
 
-					cmd := exec.Command("go", args...)
-					cmd.Dir = tooling.Home()
+					// The suite is compiled into this binary, run it in a child
+					// process so a panicking test cannot take the CLI down.
+					cmd := exec.Command(executable, append([]string{runSuiteCommand}, args...)...)
 					cmd.Env = env
 					cmd.Stdout = out{
 						Writer: testWriter,
@@ -222,10 +475,25 @@ func main() {
 					fmt.Println()
 					testErr := cmd.Run()
 
-					// Close pipe to signal EOF to test2json, then wait for it
-					if pipeWriter != nil {
-						pipeWriter.Close()
-						if err := test2json.Wait(); err != nil && testErr == nil {
+					// Emit the final suite event
+					if converter != nil {
+						converter.Exited(testErr)
+						converter.Close()
+
+						if parallel > 1 {
+							if err := report.SortEvents(io.MultiWriter(jsonWriters...), unsorted); err != nil {
+								return err
+							}
+						}
+					}
+
+					var r *report.Report
+					if collectEvents {
+						r, err = report.Parse(events)
+						if err != nil {
+							return err
+						}
+						if err := reports.write(r); err != nil {
 							return err
 						}
 					}
@@ -255,9 +523,42 @@ func main() {
 						fmt.Println()
 					}
 
+					// A run that failed without failing tests (e.g. a panic in
+					// the suite) is never covered by the baseline.
+					runFailed := testErr != nil && r != nil && len(report.NewBaseline(r)) == 0
+
+					if writeBaselinePath != "" {
+						if err := writeBaseline(writeBaselinePath, r); err != nil {
+							return err
+						}
+						if !runFailed {
+							return nil
+						}
+					}
+
+					if baselinePath != "" {
+						if err := checkBaseline(baselinePath, r); err != nil {
+							return err
+						}
+						if !runFailed {
+							return nil
+						}
+					}
+
 					return testErr
 				},
 			},
+			{
+				Name:            runSuiteCommand,
+				Usage:           "Run the compiled-in test suite, accepts the same flags as a `go test` binary",
+				Hidden:          true,
+				SkipFlagParsing: true,
+				Action: func(cctx *cli.Context) error {
+					os.Args = append([]string{os.Args[0]}, cctx.Args().Slice()...)
+					tests.Main()
+					return nil
+				},
+			},
 			{
 				Name:    "extract-fixtures",
 				Aliases: []string{"e"},
@@ -289,6 +590,16 @@ func main() {
 						Usage: "Include DNSLink fixtures",
 						Value: true,
 					},
+					&cli.BoolFlag{
+						Name:  "synthetic",
+						Usage: "Generate the large synthetic UnixFS fixtures into the synthetic subdirectory",
+						Value: false,
+					},
+					&cli.Int64Flag{
+						Name:  "synthetic-max-size",
+						Usage: "Skip the synthetic fixtures with more bytes of file data",
+						Value: 64 << 20,
+					},
 				},
 				Action: func(cctx *cli.Context) error {
 					directory := cctx.String("directory")
@@ -345,9 +656,184 @@ func main() {
 						}
 					}
 
+					// Synthetic fixtures are too large to be embedded, generate
+					// them as separate CAR files, never merged.
+					if cctx.Bool("synthetic") {
+						err = writeSyntheticFixtures(filepath.Join(directory, "synthetic"), cctx.Int64("synthetic-max-size"))
+						if err != nil {
+							return err
+						}
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:    "serve",
+				Aliases: []string{"s"},
+				Usage:   "Start a reference gateway serving the test fixtures",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "listen",
+						Aliases: []string{"l"},
+						Usage:   "The address the gateway should listen on.",
+						Value:   "127.0.0.1:8080",
+					},
+					&cli.StringSliceFlag{
+						Name:  "subdomain-host",
+						Usage: "A hostname (e.g. example.com) on which the gateway acts as a subdomain gateway. Can be repeated.",
+						Value: cli.NewStringSlice(server.DefaultSubdomainHosts...),
+					},
+					&cli.StringFlag{
+						Name:  "synthetic",
+						Usage: "The directory of the synthetic fixtures generated by `extract-fixtures --synthetic` (e.g. fixtures/synthetic). Its CAR files are served too, when it exists.",
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					fxs, err := fixtures.List()
+					if err != nil {
+						return err
+					}
+
+					// Synthetic fixtures are not part of the embedded fixtures,
+					// they are only served once generated.
+					if dir := cctx.String("synthetic"); dir != "" {
+						carFiles, err := filepath.Glob(filepath.Join(dir, "*.car"))
+						if err != nil {
+							return err
+						}
+						if len(carFiles) == 0 {
+							fmt.Printf("No synthetic fixtures in %s, generate them with `extract-fixtures --synthetic`\n", dir)
+						}
+						fxs.CarFiles = append(fxs.CarFiles, carFiles...)
+					}
+
+					handler, err := server.NewHandler(fxs, cctx.StringSlice("subdomain-host")...)
+					if err != nil {
+						return err
+					}
+
+					listen := cctx.String("listen")
+					fmt.Printf("Serving %d CAR files, %d IPNS records and %d DNSLink configs on http://%s\n", len(fxs.CarFiles), len(fxs.IPNSRecords), len(fxs.ConfigFiles), listen)
+
+					return http.ListenAndServe(listen, handler)
+				},
+			},
+			{
+				Name:      "aggregate",
+				Usage:     "Aggregate the JSON reports of many test runs into the web dashboard database and content",
+				ArgsUsage: "<report.json>...",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "db",
+						Usage: "The path of the SQLite database the results are stored in.",
+						Value: "aggregates.db",
+					},
+					&cli.StringFlag{
+						Name:  "www",
+						Usage: "The Hugo website directory where the dashboard data and content should be generated. Skipped when empty.",
+						Value: "www",
+					},
+					&cli.StringFlag{
+						Name:  "table",
+						Usage: "The path where a Markdown table comparing the reports should be generated.",
+						Value: "",
+					},
+					&cli.BoolFlag{
+						Name:  "github-run-details",
+						Usage: "Fetch the commit, branch and start time of GitHub Actions job URLs (uses GH_TOKEN when set).",
+						Value: true,
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					files := cctx.Args().Slice()
+					if len(files) == 0 {
+						return cli.Exit("⚠️ at least one JSON report is required", 2)
+					}
+
+					db, err := aggregate.Open(cctx.String("db"))
+					if err != nil {
+						return err
+					}
+					defer db.Close()
+
+					var runs []aggregate.Run
+					for _, file := range files {
+						fmt.Printf("Processing %s...\n", file)
+						r, err := parseReport(file)
+						if err != nil {
+							return err
+						}
+
+						id := aggregate.ImplementationID(file)
+						if err := aggregate.Ingest(db, id, r); err != nil {
+							return fmt.Errorf("error ingesting %s: %w", file, err)
+						}
+						runs = append(runs, aggregate.Run{Name: id, Report: r})
+					}
+
+					if www := cctx.String("www"); www != "" {
+						var runDetails aggregate.RunDetailsFunc
+						if cctx.Bool("github-run-details") {
+							runDetails = aggregate.GitHubRunDetails(os.Getenv("GH_TOKEN"))
+						}
+						if err := aggregate.Generate(cctx.Context, db, www, runDetails); err != nil {
+							return err
+						}
+					}
+
+					if table := cctx.String("table"); table != "" {
+						f, err := createOutput(table)
+						if err != nil {
+							return err
+						}
+						defer f.Close()
+						if err := aggregate.WriteTable(f, runs); err != nil {
+							return err
+						}
+					}
+
 					return nil
 				},
 			},
+			{
+				Name:      "diff",
+				Usage:     "Compare the JSON reports of two test runs",
+				ArgsUsage: "<before.json> <after.json>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "The output format: 'text', or 'json' for bots and scripts.",
+						Value: "text",
+					},
+				},
+				Action: func(cctx *cli.Context) error {
+					if cctx.NArg() != 2 {
+						return cli.Exit("⚠️ two JSON reports are required", 2)
+					}
+
+					var write func(io.Writer, *report.DiffResult) error
+					switch format := cctx.String("format"); format {
+					case "text":
+						write = report.WriteDiffText
+					case "json":
+						write = report.WriteDiffJSON
+					default:
+						return cli.Exit(fmt.Sprintf("⚠️ unknown format %q, expected 'text' or 'json'", format), 2)
+					}
+
+					before, err := parseReport(cctx.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					after, err := parseReport(cctx.Args().Get(1))
+					if err != nil {
+						return err
+					}
+
+					return write(os.Stdout, report.Diff(before, after))
+				},
+			},
 		},
 	}
 
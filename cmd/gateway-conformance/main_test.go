@@ -2,8 +2,16 @@ package main
 
 import (
 	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/ipfs/gateway-conformance/tests"
+	"github.com/ipfs/gateway-conformance/tooling"
 )
 
 // Test cases for isSubdomainPresetEnabled function
@@ -190,3 +198,107 @@ func TestTransformWriterChunked(t *testing.T) {
 		})
 	}
 }
+
+func TestTestFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "go test flags are prefixed",
+			args: []string{"-timeout", "30m", "-run=TestCors", "--skip", "TestTar"},
+			want: []string{"-test.timeout", "30m", "-test.run=TestCors", "-test.skip", "TestTar"},
+		},
+		{
+			name: "test binary flags are unchanged",
+			args: []string{"-test.run=TestCors", "-specs=-subdomain-gateway"},
+			want: []string{"-test.run=TestCors", "-specs=-subdomain-gateway"},
+		},
+		{
+			name: "values are unchanged",
+			args: []string{"-run", "TestGatewayCache/v"},
+			want: []string{"-test.run", "TestGatewayCache/v"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testFlags(tt.args)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{
+			name: "flag with a separate value",
+			args: []string{"-test.run", "TestCors", "-test.timeout", "30m"},
+			want: true,
+		},
+		{
+			name: "flag with an inline value",
+			args: []string{"--test.timeout=30m"},
+			want: true,
+		},
+		{
+			name: "value of another flag",
+			args: []string{"-test.run", "test.timeout"},
+			want: false,
+		},
+		{
+			name: "no flag",
+			args: nil,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasFlag(tt.args, "test.timeout"); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Tests live in regular files of the tests package and must be registered in
+// tests.All() to be compiled into the binary; make sure none is forgotten.
+func TestSuiteRegistersAllTests(t *testing.T) {
+	registered := map[string]bool{}
+	for _, test := range tests.All() {
+		registered[test.Name] = true
+	}
+
+	files, err := filepath.Glob(filepath.Join(tooling.Home(), "tests", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Test") {
+				continue
+			}
+			if !registered[fn.Name.Name] {
+				t.Errorf("%s: %s is not registered in tests.All()", filepath.Base(file), fn.Name.Name)
+			}
+		}
+	}
+}
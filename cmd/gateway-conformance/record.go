@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// recordingFile stores the gateway URLs of a recorded run (--record), so the
+// run can be replayed (--replay) with the same URLs, and thus the same
+// requests.
+const recordingFile = "recording.json"
+
+type recording struct {
+	GatewayURL          string `json:"gateway_url"`
+	SubdomainGatewayURL string `json:"subdomain_gateway_url,omitempty"`
+}
+
+func writeRecording(dir string, rec recording) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, recordingFile), b, 0644)
+}
+
+func readRecording(dir string) (recording, error) {
+	var rec recording
+	b, err := os.ReadFile(filepath.Join(dir, recordingFile))
+	if err != nil {
+		return rec, err
+	}
+	err = json.Unmarshal(b, &rec)
+	return rec, err
+}
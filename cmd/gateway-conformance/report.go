@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/gateway-conformance/tooling/report"
+)
+
+// reportOutputs holds the paths of the reports generated from the test2json
+// events of a run, empty paths are skipped.
+type reportOutputs struct {
+	junit    string
+	html     string
+	markdown string
+	json     string
+}
+
+func (o reportOutputs) any() bool {
+	return o.junit != "" || o.html != "" || o.markdown != "" || o.json != ""
+}
+
+func (o reportOutputs) write(r *report.Report) error {
+	for _, output := range []struct {
+		path  string
+		write func(io.Writer, *report.Report) error
+	}{
+		{o.junit, report.WriteJUnit},
+		{o.html, report.WriteHTML},
+		{o.markdown, report.WriteMarkdown},
+		{o.json, report.WriteJSON},
+	} {
+		if output.path == "" {
+			continue
+		}
+		if err := writeReport(output.path, r, output.write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeReport(path string, r *report.Report, write func(io.Writer, *report.Report) error) error {
+	f, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// createOutput creates a report file, and its parent directories.
+func createOutput(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+func parseReport(path string) (*report.Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := report.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// checkBaseline compares the failures of a run with the baseline at path and
+// prints the differences. It returns an error when there are new failures.
+func checkBaseline(path string, r *report.Report) error {
+	baseline, err := report.LoadBaseline(path)
+	if err != nil {
+		return err
+	}
+
+	result := r.CompareBaseline(baseline)
+	fmt.Printf("\nBaseline %s: %d expected failure(s), %d new failure(s), %d unexpectedly passing\n", path, len(result.ExpectedFailures), len(result.NewFailures), len(result.UnexpectedlyPassing))
+	if len(result.UnexpectedlyPassing) > 0 {
+		fmt.Println("\nUnexpectedly passing, remove them from the baseline:")
+		for _, name := range result.UnexpectedlyPassing {
+			fmt.Println("  " + name)
+		}
+	}
+	if len(result.NewFailures) > 0 {
+		fmt.Println("\nNew failures:")
+		for _, name := range result.NewFailures {
+			fmt.Println("  " + name)
+		}
+		return fmt.Errorf("%d test(s) failed that are not in the baseline", len(result.NewFailures))
+	}
+	return nil
+}
+
+// writeBaseline writes the failures of a run as a baseline to path.
+func writeBaseline(path string, r *report.Report) error {
+	baseline := report.NewBaseline(r)
+	f, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	if err := baseline.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	fmt.Printf("\nWrote %d failure(s) to the baseline %s\n", len(baseline), path)
+	return f.Close()
+}
@@ -0,0 +1,26 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/test"
+)
+
+// TestDeclarative runs the SugarTests written in the YAML and JSON files given
+// with -tests, see docs/test-dsl-syntax.md.
+func TestDeclarative(t *testing.T) {
+	if len(test.SugarTestPaths) == 0 {
+		t.Skip("skipping tests, no declarative test files given with -tests")
+	}
+
+	files, err := test.LoadSugarTestFiles(test.SugarTestPaths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		t.Run(f.Name, func(t *testing.T) {
+			f.Run(t)
+		})
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/ipfs/gateway-conformance/tooling"
+	"github.com/ipfs/gateway-conformance/tooling/helpers"
 	"github.com/ipfs/gateway-conformance/tooling/specs"
+	"github.com/ipfs/gateway-conformance/tooling/test"
 )
 
 type specsFlag string
@@ -62,4 +65,38 @@ var specsFlagValue specsFlag
 
 func init() {
 	flag.Var(&specsFlagValue, "specs", "A comma-separated list of specs to be tested. Accepts a spec (test only this spec), a +spec (test also this immature spec), or a -spec (do not test this mature spec). Defaults to all mature specs.")
+	flag.StringVar(&tooling.JobURL, "job-url", tooling.JobURL, "The Job URL where this run will be visible.")
+	flag.Func("group", "A comma-separated list of test groups to run, \"Other\" selects the tests without a group. Defaults to all groups.", func(value string) error {
+		tooling.Groups = append(tooling.Groups, splitList(value)...)
+		return nil
+	})
+	flag.Func("spec-url", "A comma-separated list of spec URLs, only the tests covering these specs (or their sections) are run.", func(value string) error {
+		tooling.SpecURLs = append(tooling.SpecURLs, splitList(value)...)
+		return nil
+	})
+	flag.Func("tests", "A comma-separated list of YAML or JSON files of declarative tests, or of directories of such files, run by TestDeclarative.", func(value string) error {
+		test.SugarTestPaths = append(test.SugarTestPaths, splitList(value)...)
+		return nil
+	})
+	flag.BoolFunc("head", "Also send every GET test as a HEAD request, expecting the same status and headers and an empty body.", func(string) error {
+		test.Transforms = append(test.Transforms, helpers.HEADRequestTransforms)
+		return nil
+	})
+	flag.IntVar(&test.Retries, "retries", test.Retries, "The number of times a request is retried after a transport error or an unexpected 5xx response.")
+	flag.BoolVar(&test.RetryGETOnly, "retry-get-only", test.RetryGETOnly, "Only retry GET and HEAD requests.")
+	flag.StringVar(&test.RecordDir, "record", test.RecordDir, "The directory where every HTTP request and response should be recorded, as HAR files.")
+	flag.IntVar(&test.Parallel, "concurrency", test.Parallel, "The number of subtests of a test that may run at the same time.")
+	flag.DurationVar(&test.TestTimeout, "test-timeout", test.TestTimeout, "The maximum duration of a subtest, all its requests included.")
+	flag.DurationVar(&test.RequestTimeout, "request-timeout", test.RequestTimeout, "The maximum duration of a single request, reading the response body included. Zero means no limit other than -test-timeout.")
+	flag.StringVar(&test.ReplayDir, "replay", test.ReplayDir, "A directory recorded with -record. The tests are run against the recorded responses instead of the gateway.")
+}
+
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/ipfs/gateway-conformance/tooling"
+	"github.com/ipfs/gateway-conformance/tooling/helpers"
 	"github.com/ipfs/gateway-conformance/tooling/specs"
 	. "github.com/ipfs/gateway-conformance/tooling/test"
 )
@@ -55,5 +56,5 @@ func TestCors(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayUnixFS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, tests), specs.PathGatewayUnixFS)
 }
@@ -86,7 +86,7 @@ func TestGatewayJsonCbor(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayDAG)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayDAG)
 }
 
 // ## IPIP-0524: Codec mismatch returns 406 Not Acceptable
@@ -193,7 +193,7 @@ func TestCodecMismatchReturns406(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayDAG)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayDAG)
 }
 
 // # Requesting CID with plain json (0x0200) and cbor (0x51) codecs
@@ -205,10 +205,10 @@ func TestPlainCodec(t *testing.T) {
 		Name        string
 		Format      string
 		Disposition string
-		Checker     func(value []byte) Check[[]byte]
+		Codec       string
 	}{
-		{"plain JSON codec", "json", "inline", IsJSONEqual},
-		{"plain CBOR codec", "cbor", "attachment", IsEqualBytes},
+		{"plain JSON codec", "json", "inline", "json"},
+		{"plain CBOR codec", "cbor", "attachment", "dag-cbor"},
 	}
 
 	for _, row := range table {
@@ -275,7 +275,7 @@ func TestPlainCodec(t *testing.T) {
 					Fmt("application/{{format}}", row.Format),
 				)...)
 
-		RunWithSpecs(t, tests, specs.PathGatewayDAG)
+		RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayDAG)
 	}
 }
 
@@ -306,9 +306,7 @@ func TestPathing(t *testing.T) {
 			Response: Expect().
 				Status(200).
 				Body(
-					// TODO: I like that this text is readable and easy to understand.
-					// 		 but we might prefer matching abstract values, something like "IsJSONEqual(someFixture.formatedAsJSON))"
-					IsJSONEqual([]byte(`{"hello": "this is not a link"}`)),
+					IsDagNode("dag-json").EqualsEncoded([]byte(`{"hello": "this is not a link"}`)),
 				),
 		},
 		{
@@ -335,7 +333,7 @@ func TestPathing(t *testing.T) {
 				Status(200).
 				Body(
 					// CBOR bytes for {"hello": "this is not a link"}
-					IsEqualBytes([]byte{0xa1, 0x65, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x72, 0x74, 0x68, 0x69, 0x73, 0x20, 0x69, 0x73, 0x20, 0x6e, 0x6f, 0x74, 0x20, 0x61, 0x20, 0x6c, 0x69, 0x6e, 0x6b}),
+					IsDagNode("dag-cbor").EqualsEncoded([]byte{0xa1, 0x65, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x72, 0x74, 0x68, 0x69, 0x73, 0x20, 0x69, 0x73, 0x20, 0x6e, 0x6f, 0x74, 0x20, 0x61, 0x20, 0x6c, 0x69, 0x6e, 0x6b}),
 				),
 		},
 		{
@@ -347,7 +345,7 @@ func TestPathing(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayDAG)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayDAG)
 }
 
 // ## NATIVE TESTS for DAG-JSON (0x0129) and DAG-CBOR (0x71):
@@ -361,10 +359,10 @@ func TestNativeDag(t *testing.T) {
 		Name        string
 		Format      string
 		Disposition string
-		Checker     func(value []byte) Check[[]byte]
+		Codec       string
 	}{
-		{"plain JSON codec", "json", "inline", IsJSONEqual},
-		{"plain CBOR codec", "cbor", "attachment", IsEqualBytes},
+		{"plain JSON codec", "json", "inline", "json"},
+		{"plain CBOR codec", "cbor", "attachment", "dag-cbor"},
 	}
 
 	for _, row := range table {
@@ -381,7 +379,7 @@ func TestNativeDag(t *testing.T) {
 				Response: Expect().
 					Status(200).
 					Body(
-						row.Checker(formatted),
+						IsDagNode(row.Codec).EqualsEncoded(formatted),
 					),
 			},
 			{
@@ -393,7 +391,7 @@ func TestNativeDag(t *testing.T) {
 				Response: Expect().
 					Status(200).
 					Body(
-						row.Checker(formatted),
+						IsDagNode(row.Codec).EqualsEncoded(formatted),
 					),
 			},
 			{
@@ -404,7 +402,7 @@ func TestNativeDag(t *testing.T) {
 				Response: Expect().
 					Status(200).
 					Body(
-						row.Checker(formatted),
+						IsDagNode(row.Codec).EqualsEncoded(formatted),
 					),
 			},
 			{
@@ -417,7 +415,7 @@ func TestNativeDag(t *testing.T) {
 					Status(200).
 					Header(Header("Content-Type", "application/{{format}}", row.Format)).
 					Body(
-						row.Checker(formatted),
+						IsDagNode(row.Codec).EqualsEncoded(formatted),
 					),
 			},
 			{
@@ -429,7 +427,7 @@ func TestNativeDag(t *testing.T) {
 					Status(200).
 					Header(Header("Content-Type", "application/{{format}}", row.Format)).
 					Body(
-						row.Checker(formatted),
+						IsDagNode(row.Codec).EqualsEncoded(formatted),
 					),
 			},
 			{
@@ -581,7 +579,7 @@ func TestNativeDag(t *testing.T) {
 				Fmt("application/{{format}}", row.Format),
 			)...)
 
-		RunWithSpecs(t, tests, specs.PathGatewayDAG)
+		RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayDAG)
 	}
 
 	// Test that DAG-CBOR can be rendered as HTML. This is not codec conversion,
@@ -690,5 +688,5 @@ func TestGatewayJSONCborAndIPNS(t *testing.T) {
 		}...)
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayDAG, specs.PathGatewayIPNS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayDAG, specs.PathGatewayIPNS)
 }
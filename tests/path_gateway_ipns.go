@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/ipfs/gateway-conformance/tooling"
+	"github.com/ipfs/gateway-conformance/tooling/helpers"
 	. "github.com/ipfs/gateway-conformance/tooling/ipns"
 	"github.com/ipfs/gateway-conformance/tooling/specs"
 	. "github.com/ipfs/gateway-conformance/tooling/test"
@@ -124,7 +125,7 @@ func TestGatewayIPNSPath(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayIPNS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, tests), specs.PathGatewayIPNS)
 }
 
 func TestRedirectCanonicalIPNS(t *testing.T) {
@@ -163,7 +164,7 @@ func TestRedirectCanonicalIPNS(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayIPNS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, tests), specs.PathGatewayIPNS)
 }
 
 func TestGatewayIPNSRecordWithSubpath(t *testing.T) {
@@ -189,5 +190,5 @@ func TestGatewayIPNSRecordWithSubpath(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayIPNS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, tests), specs.PathGatewayIPNS)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ipfs/gateway-conformance/tooling"
 	"github.com/ipfs/gateway-conformance/tooling/car"
+	"github.com/ipfs/gateway-conformance/tooling/helpers"
 	"github.com/ipfs/gateway-conformance/tooling/specs"
 	. "github.com/ipfs/gateway-conformance/tooling/test"
 )
@@ -143,5 +144,5 @@ func TestGatewayBlock(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayRaw)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayRaw)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/ipfs/gateway-conformance/tooling"
 	"github.com/ipfs/gateway-conformance/tooling/car"
 	. "github.com/ipfs/gateway-conformance/tooling/check"
+	"github.com/ipfs/gateway-conformance/tooling/helpers"
 	"github.com/ipfs/gateway-conformance/tooling/specs"
 	. "github.com/ipfs/gateway-conformance/tooling/test"
 	"github.com/ipfs/gateway-conformance/tooling/tmpl"
@@ -112,5 +113,5 @@ func TestTar(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayTAR)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayTAR)
 }
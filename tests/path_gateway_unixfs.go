@@ -7,6 +7,7 @@ import (
 	"github.com/ipfs/gateway-conformance/tooling"
 	"github.com/ipfs/gateway-conformance/tooling/car"
 	. "github.com/ipfs/gateway-conformance/tooling/check"
+	"github.com/ipfs/gateway-conformance/tooling/helpers"
 	"github.com/ipfs/gateway-conformance/tooling/ipns"
 	"github.com/ipfs/gateway-conformance/tooling/specs"
 	. "github.com/ipfs/gateway-conformance/tooling/test"
@@ -75,7 +76,9 @@ func TestUnixFSDirectoryListing(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayUnixFS)
+	// The links of the HTML listing are path gateway specific, the subdomain
+	// gateway listings are tested in TestUnixFSDirectoryListingOnSubdomainGateway.
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, tests), specs.PathGatewayUnixFS)
 }
 
 func TestGatewayCache(t *testing.T) {
@@ -260,7 +263,7 @@ func TestGatewayCache(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayUnixFS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayUnixFS)
 
 	// DirIndex etagDir is based on xxhash(./assets/dir-index-html), so we need to fetch it dynamically
 	var etagDir string
@@ -281,7 +284,7 @@ func TestGatewayCache(t *testing.T) {
 				),
 		},
 	}
-	RunWithSpecs(t, testsA, specs.PathGatewayUnixFS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, testsA), specs.PathGatewayUnixFS)
 
 	testsB := SugarTests{
 		{
@@ -305,7 +308,7 @@ func TestGatewayCache(t *testing.T) {
 				Status(304),
 		},
 	}
-	RunWithSpecs(t, testsB, specs.PathGatewayUnixFS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, testsB), specs.PathGatewayUnixFS)
 }
 
 func TestGatewayCacheWithIPNS(t *testing.T) {
@@ -414,7 +417,7 @@ func TestGatewayCacheWithIPNS(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayUnixFS, specs.PathGatewayIPNS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayUnixFS, specs.PathGatewayIPNS)
 }
 
 func TestGatewaySymlink(t *testing.T) {
@@ -453,7 +456,7 @@ func TestGatewaySymlink(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayUnixFS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayUnixFS)
 }
 
 func TestGatewayUnixFSFileRanges(t *testing.T) {
@@ -531,15 +534,10 @@ func TestGatewayUnixFSFileRanges(t *testing.T) {
 				// Option B: server returns all ranges as multipart
 				Expect().
 					Status(206).
-					Headers(
-						Header("Content-Type").Contains("multipart/byteranges"),
-					).
-					Body(And(
-						Contains("Content-Range: bytes 6-16/31"),
-						Contains(string(fixture.MustGetRawData("ascii.txt")[6:17])),
-						Contains("Content-Range: bytes 0-4/31"),
-						Contains(string(fixture.MustGetRawData("ascii.txt")[0:5])),
-					)),
+					Body(IsMultipartByteranges().
+						WithContentType("text/plain; charset=utf-8").
+						WithPart("bytes 6-16/31", fixture.MustGetRawData("ascii.txt")[6:17]).
+						WithPart("bytes 0-4/31", fixture.MustGetRawData("ascii.txt")[0:5])),
 			),
 		},
 	}
@@ -577,7 +575,19 @@ func TestGatewayUnixFSFileRanges(t *testing.T) {
 			),
 	})
 
-	RunWithSpecs(t, tests, specs.PathGatewayRange, specs.PathGatewayUnixFS)
+	tests = append(tests, helpers.RangeGrammarTests(t,
+		SugarTest{
+			Name: "GET for /ipfs/ file",
+			Spec: "https://specs.ipfs.tech/http-gateways/path-gateway/#range-request-header",
+			Request: Request().
+				Path("/ipfs/{{cid}}/ascii.txt", fixture.MustGetCid()),
+			Response: Expect(),
+		},
+		fixture.MustGetRawData("ascii.txt"),
+		"text/plain; charset=utf-8",
+	)...)
+
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayRange, specs.PathGatewayUnixFS)
 }
 
 func TestPathGatewayMiscellaneous(t *testing.T) {
@@ -594,5 +604,5 @@ func TestPathGatewayMiscellaneous(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.PathGatewayUnixFS)
+	RunWithSpecs(t, helpers.SubdomainGatewayTransforms(t, helpers.ConditionalRequestTransforms(t, tests)), specs.PathGatewayUnixFS)
 }
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// All returns every conformance test of the suite, in the order `go test`
+// would run them (files sorted by name, then declaration order).
+//
+// The tests live in regular (non _test.go) files so they are compiled into the
+// gateway-conformance binary. Remember to register new tests here.
+func All() []testing.InternalTest {
+	return []testing.InternalTest{
+		// declarative.go
+		{Name: "TestDeclarative", F: TestDeclarative},
+		// dnslink_gateway_ipns.go
+		{Name: "TestDNSLinkGatewayIPNS", F: TestDNSLinkGatewayIPNS},
+		// dnslink_gateway.go
+		{Name: "TestDNSLinkGatewayUnixFSDirectoryListing", F: TestDNSLinkGatewayUnixFSDirectoryListing},
+		{Name: "TestDNSLinkGatewayWithSubpath", F: TestDNSLinkGatewayWithSubpath},
+		// metadata.go
+		{Name: "TestMetadata", F: TestMetadata},
+		// path_gateway_cors.go
+		{Name: "TestCors", F: TestCors},
+		// path_gateway_dag.go
+		{Name: "TestGatewayJsonCbor", F: TestGatewayJsonCbor},
+		{Name: "TestCodecMismatchReturns406", F: TestCodecMismatchReturns406},
+		{Name: "TestPlainCodec", F: TestPlainCodec},
+		{Name: "TestPathing", F: TestPathing},
+		{Name: "TestNativeDag", F: TestNativeDag},
+		{Name: "TestGatewayJSONCborAndIPNS", F: TestGatewayJSONCborAndIPNS},
+		// path_gateway_ipns.go
+		{Name: "TestGatewayIPNSPath", F: TestGatewayIPNSPath},
+		{Name: "TestRedirectCanonicalIPNS", F: TestRedirectCanonicalIPNS},
+		{Name: "TestGatewayIPNSRecordWithSubpath", F: TestGatewayIPNSRecordWithSubpath},
+		// path_gateway_raw.go
+		{Name: "TestGatewayBlock", F: TestGatewayBlock},
+		// path_gateway_tar.go
+		{Name: "TestTar", F: TestTar},
+		// path_gateway_unixfs.go
+		{Name: "TestUnixFSDirectoryListing", F: TestUnixFSDirectoryListing},
+		{Name: "TestGatewayCache", F: TestGatewayCache},
+		{Name: "TestGatewayCacheWithIPNS", F: TestGatewayCacheWithIPNS},
+		{Name: "TestGatewaySymlink", F: TestGatewaySymlink},
+		{Name: "TestGatewayUnixFSFileRanges", F: TestGatewayUnixFSFileRanges},
+		{Name: "TestPathGatewayMiscellaneous", F: TestPathGatewayMiscellaneous},
+		// redirects_file.go
+		{Name: "TestRedirectsFileSupport", F: TestRedirectsFileSupport},
+		{Name: "TestRedirectsFileSupportWithDNSLink", F: TestRedirectsFileSupportWithDNSLink},
+		{Name: "TestRedirectsFileWithIfNoneMatchHeader", F: TestRedirectsFileWithIfNoneMatchHeader},
+		// subdomain_gateway_ipfs.go
+		{Name: "TestUnixFSDirectoryListingOnSubdomainGateway", F: TestUnixFSDirectoryListingOnSubdomainGateway},
+		{Name: "TestGatewaySubdomains", F: TestGatewaySubdomains},
+		// subdomain_gateway_ipns.go
+		{Name: "TestGatewaySubdomainAndIPNS", F: TestGatewaySubdomainAndIPNS},
+		{Name: "TestSubdomainGatewayDNSLinkInlining", F: TestSubdomainGatewayDNSLinkInlining},
+		// subdomain_gateway_proxy.go
+		{Name: "TestProxyGatewaySubdomains", F: TestProxyGatewaySubdomains},
+		{Name: "TestProxyTunnelGatewaySubdomains", F: TestProxyTunnelGatewaySubdomains},
+		// synthetic_fixtures.go
+		{Name: "TestSyntheticLargeFile", F: TestSyntheticLargeFile},
+		// trustless_gateway_car.go
+		{Name: "TestTrustlessCarPathing", F: TestTrustlessCarPathing},
+		{Name: "TestTrustlessCarDagScopeBlock", F: TestTrustlessCarDagScopeBlock},
+		{Name: "TestTrustlessCarDagScopeEntity", F: TestTrustlessCarDagScopeEntity},
+		{Name: "TestTrustlessCarDagScopeAll", F: TestTrustlessCarDagScopeAll},
+		{Name: "TestTrustlessCarEntityBytes", F: TestTrustlessCarEntityBytes},
+		{Name: "TestTrustlessCarTraversal", F: TestTrustlessCarTraversal},
+		{Name: "TestTrustlessCarOrderAndDuplicates", F: TestTrustlessCarOrderAndDuplicates},
+		{Name: "TestTrustlessCarFormatPrecedence", F: TestTrustlessCarFormatPrecedence},
+		{Name: "TestTrustlessCarContentTypeParameters", F: TestTrustlessCarContentTypeParameters},
+		{Name: "TestTrustlessCarV2", F: TestTrustlessCarV2},
+		// trustless_gateway_ipns.go
+		{Name: "TestGatewayIPNSRecord", F: TestGatewayIPNSRecord},
+		// trustless_gateway_raw.go
+		{Name: "TestTrustlessRaw", F: TestTrustlessRaw},
+		{Name: "TestTrustlessRawRanges", F: TestTrustlessRawRanges},
+	}
+}
+
+// Main runs the suite the same way a `go test` binary would: test flags
+// (-test.run, -test.v, ...) and the -specs flag are parsed from os.Args.
+// It calls os.Exit when done.
+func Main() {
+	testing.Main(matchString, All(), nil, nil)
+}
+
+var (
+	matchPat string
+	matchRe  *regexp.Regexp
+	matchMu  sync.Mutex
+)
+
+func matchString(pat, str string) (bool, error) {
+	matchMu.Lock()
+	defer matchMu.Unlock()
+
+	if matchRe == nil || matchPat != pat {
+		re, err := regexp.Compile(pat)
+		if err != nil {
+			return false, err
+		}
+		matchPat = pat
+		matchRe = re
+	}
+	return matchRe.MatchString(str), nil
+}
@@ -0,0 +1,19 @@
+package tests
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the registered suite instead of the tests discovered by
+// `go test`, so `go test ./tests` and the gateway-conformance binary share a
+// single list of tests (see All).
+func TestMain(m *testing.M) {
+	// testing.Main cannot write the log `go test` uses for caching results.
+	os.Args = slices.DeleteFunc(os.Args, func(arg string) bool {
+		return strings.HasPrefix(arg, "-test.testlogfile=")
+	})
+	Main()
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"io"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling"
+	"github.com/ipfs/gateway-conformance/tooling/car"
+	. "github.com/ipfs/gateway-conformance/tooling/check"
+	"github.com/ipfs/gateway-conformance/tooling/helpers"
+	"github.com/ipfs/gateway-conformance/tooling/specs"
+	. "github.com/ipfs/gateway-conformance/tooling/test"
+)
+
+// The synthetic fixtures are too large to be embedded, the gateway must import
+// the CAR files generated by `extract-fixtures --synthetic` first. Their tests
+// are behind the draft synthetic-fixtures spec, disabled by default.
+
+func TestSyntheticLargeFile(t *testing.T) {
+	tooling.LogTestGroup(t, GroupUnixFS)
+	tooling.LogSpecs(t,
+		"https://specs.ipfs.tech/http-gateways/path-gateway/#range-request-header",
+		"https://specs.ipfs.tech/http-gateways/trustless-gateway/#car-responses-application-vnd-ipld-car",
+	)
+
+	// Generating the fixture takes a while, skip before loading it.
+	if !specs.SyntheticFixtures.IsEnabled() {
+		t.Skipf("skipping tests, missing specs: %v", []specs.Spec{specs.SyntheticFixtures})
+	}
+
+	// 16MiB in 4096 raw leaves, under a balanced DAG of 3 levels.
+	const name = "file-16MiB-balanced-raw-4KiB-chunks"
+	fixture := car.MustOpenSyntheticCar(name)
+	path := "/ipfs/" + fixture.MustGetCid()
+
+	synthetic, err := car.GetSyntheticFixture(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(synthetic.Source.(car.SyntheticFile).Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The gateway sniffs the type of the pseudo-random content, any type is
+	// accepted.
+	tests := helpers.RangeGrammarTests(t,
+		SugarTest{
+			Name: "GET for a large /ipfs/ file",
+			Request: Request().
+				Path(path),
+			Response: Expect(),
+		},
+		data,
+		"",
+	)
+
+	tests = append(tests, SugarTest{
+		Name: "GET CAR of a large file has all its blocks, in order",
+		Hint: `
+			The CAR of a file of thousands of blocks is checked as it is
+			received, without keeping its blocks in memory.
+		`,
+		Request: Request().
+			Path(path).
+			Query("dag-scope", "all").
+			Header("Accept", "application/vnd.ipld.car; order=dfs"),
+		Response: Expect().
+			Status(200).
+			Headers(
+				Header("Content-Type").Contains("application/vnd.ipld.car"),
+			).
+			Body(
+				Streaming(
+					IsCar().
+						IgnoreRoots().
+						SatisfiesRequestOf(fixture, path, "all", "").
+						Exactly().
+						InThatOrder(),
+				),
+			),
+	})
+
+	RunWithSpecs(t, tests, specs.SyntheticFixtures)
+}
@@ -1,10 +1,12 @@
 package tests
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/ipfs/gateway-conformance/tooling"
 	"github.com/ipfs/gateway-conformance/tooling/car"
+	"github.com/ipfs/gateway-conformance/tooling/car/traversal"
 	. "github.com/ipfs/gateway-conformance/tooling/check"
 	"github.com/ipfs/gateway-conformance/tooling/helpers"
 	"github.com/ipfs/gateway-conformance/tooling/specs"
@@ -134,7 +136,7 @@ func TestTrustlessCarPathing(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, helpers.StandardCARTestTransforms(t, tests), specs.TrustlessGatewayCAR)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, helpers.StandardCARTestTransforms(t, tests)), specs.TrustlessGatewayCAR)
 }
 
 func TestTrustlessCarDagScopeBlock(t *testing.T) {
@@ -219,7 +221,7 @@ func TestTrustlessCarDagScopeBlock(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, helpers.StandardCARTestTransforms(t, tests), specs.TrustlessGatewayCAR)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, helpers.StandardCARTestTransforms(t, tests)), specs.TrustlessGatewayCAR)
 }
 
 func TestTrustlessCarDagScopeEntity(t *testing.T) {
@@ -355,7 +357,7 @@ func TestTrustlessCarDagScopeEntity(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, helpers.StandardCARTestTransforms(t, tests), specs.TrustlessGatewayCAR)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, helpers.StandardCARTestTransforms(t, tests)), specs.TrustlessGatewayCAR)
 }
 
 func TestTrustlessCarDagScopeAll(t *testing.T) {
@@ -420,7 +422,7 @@ func TestTrustlessCarDagScopeAll(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, helpers.StandardCARTestTransforms(t, tests), specs.TrustlessGatewayCAR)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, helpers.StandardCARTestTransforms(t, tests)), specs.TrustlessGatewayCAR)
 }
 
 func TestTrustlessCarEntityBytes(t *testing.T) {
@@ -717,7 +719,79 @@ func TestTrustlessCarEntityBytes(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, helpers.StandardCARTestTransforms(t, tests), specs.TrustlessGatewayCAR)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, helpers.StandardCARTestTransforms(t, tests)), specs.TrustlessGatewayCAR)
+}
+
+func TestTrustlessCarTraversal(t *testing.T) {
+	tooling.LogTestGroup(t, GroupBlockCar)
+
+	subdirTwoSingleBlockFilesFixture := car.MustOpenUnixfsCar("trustless_gateway_car/subdir-with-two-single-block-files.car")
+	singleLayerHamtMultiBlockFilesFixture := car.MustOpenUnixfsCar("trustless_gateway_car/single-layer-hamt-with-multi-block-files.car")
+	subdirWithMixedBlockFiles := car.MustOpenUnixfsCar("trustless_gateway_car/subdir-with-mixed-block-files.car")
+	dirWithDagCborWithLinksFixture := car.MustOpenUnixfsCar("trustless_gateway_car/dir-with-dag-cbor-with-links.car")
+
+	// The expected blocks, and their order, are computed by traversing the
+	// fixture as specified for each request.
+	rows := []struct {
+		fixture     *car.UnixfsDag
+		path        string
+		scope       traversal.Scope
+		entityBytes string
+	}{
+		{subdirTwoSingleBlockFilesFixture, "", traversal.ScopeAll, ""},
+		{subdirTwoSingleBlockFilesFixture, "/subdir/ascii.txt", traversal.ScopeEntity, ""},
+		{subdirWithMixedBlockFiles, "/subdir", traversal.ScopeEntity, ""},
+		{subdirWithMixedBlockFiles, "/subdir/multiblock.txt", traversal.ScopeBlock, ""},
+		{subdirWithMixedBlockFiles, "/subdir/multiblock.txt", traversal.ScopeEntity, "255:256"},
+		{subdirWithMixedBlockFiles, "/subdir/multiblock.txt", traversal.ScopeEntity, "256:511"},
+		{subdirWithMixedBlockFiles, "/subdir/multiblock.txt", traversal.ScopeEntity, "-1:*"},
+		{subdirWithMixedBlockFiles, "/subdir/multiblock.txt", traversal.ScopeEntity, "1024:99999"},
+		{singleLayerHamtMultiBlockFilesFixture, "", traversal.ScopeAll, ""},
+		{singleLayerHamtMultiBlockFilesFixture, "/1.txt", traversal.ScopeEntity, ""},
+		{singleLayerHamtMultiBlockFilesFixture, "/1.txt", traversal.ScopeEntity, "0:0"},
+		{dirWithDagCborWithLinksFixture, "", traversal.ScopeBlock, ""},
+		{dirWithDagCborWithLinksFixture, "/document", traversal.ScopeAll, ""},
+	}
+
+	var tests SugarTests
+	for _, row := range rows {
+		path := "/ipfs/" + row.fixture.MustGetCidWithCodec(0x70) + row.path
+
+		name := fmt.Sprintf("GET CAR of %s with dag-scope=%s", path, row.scope)
+		request := Request().
+			Path(path).
+			Query("format", "car").
+			Query("dag-scope", string(row.scope))
+		if row.entityBytes != "" {
+			name += "&entity-bytes=" + row.entityBytes
+			request = request.Query("entity-bytes", row.entityBytes)
+		}
+
+		tests = append(tests, SugarTest{
+			Name: name,
+			Hint: `
+				The response MUST contain the blocks needed to verify the path, and
+				the blocks of the dag-scope and entity-bytes, in the order of a
+				depth-first traversal.
+			`,
+			Request: request,
+			Response: Expect().
+				Status(200).
+				Body(
+					IsCar().
+						IgnoreRoots().
+						HasBlocks(traversal.MustGetBlocks(row.fixture, traversal.Request{
+							Path:        path,
+							Scope:       row.scope,
+							EntityBytes: row.entityBytes,
+						})...).
+						Exactly().
+						InThatOrder(),
+				),
+		})
+	}
+
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, helpers.StandardCARTestTransforms(t, tests)), specs.TrustlessGatewayCAR)
 }
 
 func TestTrustlessCarOrderAndDuplicates(t *testing.T) {
@@ -917,7 +991,7 @@ func TestTrustlessCarOrderAndDuplicates(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.TrustlessGatewayCAROptional)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, tests), specs.TrustlessGatewayCAROptional)
 }
 
 func TestTrustlessCarFormatPrecedence(t *testing.T) {
@@ -968,7 +1042,192 @@ func TestTrustlessCarFormatPrecedence(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.TrustlessGatewayCAR)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, tests), specs.TrustlessGatewayCAR)
+}
+
+func TestTrustlessCarContentTypeParameters(t *testing.T) {
+	tooling.LogTestGroup(t, GroupBlockCar)
+	tooling.LogSpecs(t, "https://specs.ipfs.tech/http-gateways/trustless-gateway/#car-responses-application-vnd-ipld-car")
+
+	dirWithDuplicateFiles := car.MustOpenUnixfsCar("trustless_gateway_car/dir-with-duplicate-files.car")
+	path := "/ipfs/" + dirWithDuplicateFiles.MustGetCid()
+
+	var tests SugarTests
+	for _, order := range []string{"dfs", "unk"} {
+		for _, dups := range []string{"y", "n"} {
+			// The gateway is free to pick the order for order=unk, but it
+			// must tell which one it picked.
+			contentType := IsMediaType("application/vnd.ipld.car").
+				WithParam("version", "1").
+				WithParam("dups", dups)
+			body := IsCar().IgnoreRoots().SatisfiesRequestOf(dirWithDuplicateFiles, path, "all", "")
+			if dups == "y" {
+				body = body.WithDuplicates()
+			} else {
+				body = body.HasNoDuplicates()
+			}
+			if order == "dfs" {
+				contentType = contentType.WithParam("order", "dfs")
+				body = body.Exactly().InThatOrder()
+			} else {
+				contentType = contentType.WithParam("order")
+			}
+
+			response := Expect().
+				Status(200).
+				Headers(
+					Header("Content-Type").Satisfies(contentType),
+				).
+				Body(body)
+
+			tests = append(tests,
+				SugarTest{
+					Name: fmt.Sprintf("GET CAR with Accept order=%s and dups=%s of UnixFS Directory With Duplicate Files", order, dups),
+					Hint: `
+						The Content-Type of the response MUST have the version, order and dups
+						parameters of the request, and the blocks MUST be sent accordingly.
+					`,
+					Request: Request().
+						Path(path).
+						Header("Accept", fmt.Sprintf("application/vnd.ipld.car; version=1; order=%s; dups=%s", order, dups)),
+					Response: response,
+				},
+				SugarTest{
+					Name: fmt.Sprintf("GET CAR with ?car-order=%s&car-dups=%s of UnixFS Directory With Duplicate Files", order, dups),
+					Specs: []string{
+						"https://specs.ipfs.tech/http-gateways/trustless-gateway/#car-order-request-query-parameter",
+						"https://specs.ipfs.tech/http-gateways/trustless-gateway/#car-dups-request-query-parameter",
+					},
+					Hint: `
+						The car-order and car-dups query parameters are equivalent to the
+						order and dups parameters of the Accept header.
+					`,
+					Request: Request().
+						Path(path).
+						Query("format", "car").
+						Query("car-order", order).
+						Query("car-dups", dups),
+					Response: response,
+				},
+			)
+		}
+	}
+
+	tests = append(tests, SugarTest{
+		Name: "GET CAR with an unsupported version in Accept fails the negotiation",
+		Hint: `
+			A gateway that can not produce the CAR version of the only media
+			type accepted by the client should fail the negotiation, with
+			406 Not Acceptable or 400 Bad Request.
+		`,
+		Request: Request().
+			Path(path).
+			Header("Accept", "application/vnd.ipld.car; version=99"),
+		Response: AnyOf(
+			Expect().Status(406),
+			Expect().Status(400),
+		),
+	})
+
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, tests), specs.TrustlessGatewayCAROptional)
+}
+
+func TestTrustlessCarV2(t *testing.T) {
+	tooling.LogTestGroup(t, GroupBlockCar)
+	tooling.LogSpecs(t, "https://ipld.io/specs/transport/car/carv2/")
+
+	subdirWithMixedBlockFiles := car.MustOpenUnixfsCar("trustless_gateway_car/subdir-with-mixed-block-files.car")
+	dirWithDuplicateFiles := car.MustOpenUnixfsCar("trustless_gateway_car/dir-with-duplicate-files.car")
+
+	filePath := "/ipfs/" + subdirWithMixedBlockFiles.MustGetCid() + "/subdir/multiblock.txt"
+	fileBlocks := traversal.MustGetBlocks(subdirWithMixedBlockFiles, traversal.Request{
+		Path:  filePath,
+		Scope: traversal.ScopeEntity,
+	})
+	dirPath := "/ipfs/" + dirWithDuplicateFiles.MustGetCid()
+
+	tests := SugarTests{
+		{
+			Name: "GET CAR with Accept version=2 of a chunked UnixFS file",
+			Hint: `
+				A gateway supporting CARv2 responses MUST return a CARv2, with the
+				version=2 parameter in the Content-Type, and the blocks of the request
+				in its data payload.
+			`,
+			Request: Request().
+				Path(filePath).
+				Query("dag-scope", "entity").
+				Header("Accept", "application/vnd.ipld.car; version=2"),
+			Response: Expect().
+				Status(200).
+				Headers(
+					Header("Content-Type").Satisfies(IsMediaType("application/vnd.ipld.car").WithParam("version", "2")),
+				).
+				Body(
+					IsCar().
+						WithVersion(2).
+						IgnoreRoots().
+						HasBlocks(fileBlocks...).
+						Exactly().
+						InThatOrder(),
+				),
+		},
+		{
+			Name: "GET CAR with ?car-version=2 of a chunked UnixFS file",
+			Hint: `
+				The car-version query parameter is equivalent to the version
+				parameter of the Accept header.
+			`,
+			Request: Request().
+				Path(filePath).
+				Query("format", "car").
+				Query("car-version", "2").
+				Query("dag-scope", "entity"),
+			Response: Expect().
+				Status(200).
+				Headers(
+					Header("Content-Type").Satisfies(IsMediaType("application/vnd.ipld.car").WithParam("version", "2")),
+				).
+				Body(
+					IsCar().
+						WithVersion(2).
+						IgnoreRoots().
+						HasBlocks(fileBlocks...).
+						Exactly().
+						InThatOrder(),
+				),
+		},
+		{
+			Name: "GET CAR with Accept version=2, order=dfs and dups=y of UnixFS Directory With Duplicate Files",
+			Hint: `
+				The order and dups parameters apply to the data payload of a CARv2.
+			`,
+			Request: Request().
+				Path(dirPath).
+				Header("Accept", "application/vnd.ipld.car; version=2; order=dfs; dups=y"),
+			Response: Expect().
+				Status(200).
+				Headers(
+					Header("Content-Type").Satisfies(
+						IsMediaType("application/vnd.ipld.car").
+							WithParam("version", "2").
+							WithParam("order", "dfs").
+							WithParam("dups", "y"),
+					),
+				).
+				Body(
+					IsCar().
+						WithVersion(2).
+						IgnoreRoots().
+						SatisfiesRequestOf(dirWithDuplicateFiles, dirPath, "all", "").
+						WithDuplicates().
+						Exactly().
+						InThatOrder(),
+				),
+		},
+	}
+
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, tests), specs.TrustlessGatewayCARv2)
 }
 
 // TODO: this feels like it could be an internal detail of HasBlocks
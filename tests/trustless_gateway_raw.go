@@ -9,6 +9,7 @@ import (
 
 	"github.com/ipfs/gateway-conformance/tooling"
 	"github.com/ipfs/gateway-conformance/tooling/car"
+	. "github.com/ipfs/gateway-conformance/tooling/check"
 	"github.com/ipfs/gateway-conformance/tooling/specs"
 	. "github.com/ipfs/gateway-conformance/tooling/test"
 )
@@ -27,7 +28,7 @@ func TestTrustlessRaw(t *testing.T) {
 				Query("format", "raw"),
 			Response: Expect().
 				Status(200).
-				Body(fixture.MustGetRawData("dir")),
+				Body(IsBlock(fixture.MustGetCid("dir")).WithData(fixture.MustGetRawData("dir"))),
 		},
 		{
 			Name: "GET with application/vnd.ipld.raw header returns a raw block",
@@ -38,7 +39,7 @@ func TestTrustlessRaw(t *testing.T) {
 				),
 			Response: Expect().
 				Status(200).
-				Body(fixture.MustGetRawData("dir")),
+				Body(IsBlock(fixture.MustGetCid("dir")).WithData(fixture.MustGetRawData("dir"))),
 		},
 		{
 			Name: "GET with application/vnd.ipld.raw header returns expected response headers",
@@ -59,7 +60,7 @@ func TestTrustlessRaw(t *testing.T) {
 					Header("X-Content-Type-Options").
 						Equals("nosniff"),
 				).
-				Body(fixture.MustGetRawData("dir", "ascii.txt")),
+				Body(IsBlock(fixture.MustGetCid("dir", "ascii.txt")).WithData(fixture.MustGetRawData("dir", "ascii.txt"))),
 		},
 		{
 			Name: "GET with application/vnd.ipld.raw header and filename param returns expected Content-Disposition header with custom filename",
@@ -129,7 +130,7 @@ func TestTrustlessRaw(t *testing.T) {
 		},
 	}
 
-	RunWithSpecs(t, tests, specs.TrustlessGatewayRaw)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, tests), specs.TrustlessGatewayRaw)
 }
 
 func TestTrustlessRawRanges(t *testing.T) {
@@ -141,19 +142,19 @@ func TestTrustlessRawRanges(t *testing.T) {
 	// correctly.
 	fixture := car.MustOpenUnixfsCar("gateway-raw-block.car")
 
-	tests := helpers.OnlyRandomRangeTests(t,
-		SugarTest{
-			Name: "GET with application/vnd.ipld.raw with range request includes correct bytes",
-			Request: Request().
-				Path("/ipfs/{{cid}}", fixture.MustGetCid("dir", "ascii.txt")).
-				Headers(
-					Header("Accept", "application/vnd.ipld.raw"),
-				),
-			Response: Expect(),
-		},
-		fixture.MustGetRawData("dir", "ascii.txt"),
-		"application/vnd.ipld.raw",
-	)
+	base := SugarTest{
+		Name: "GET with application/vnd.ipld.raw with range request includes correct bytes",
+		Request: Request().
+			Path("/ipfs/{{cid}}", fixture.MustGetCid("dir", "ascii.txt")).
+			Headers(
+				Header("Accept", "application/vnd.ipld.raw"),
+			),
+		Response: Expect(),
+	}
+	data := fixture.MustGetRawData("dir", "ascii.txt")
+
+	tests := helpers.OnlyRandomRangeTests(t, base, data, "application/vnd.ipld.raw")
+	tests = append(tests, helpers.RangeGrammarTests(t, base, data, "application/vnd.ipld.raw")...)
 
-	RunWithSpecs(t, tests, specs.TrustlessGatewayRaw)
+	RunWithSpecs(t, helpers.ConditionalRequestTransforms(t, tests), specs.TrustlessGatewayRaw)
 }
@@ -0,0 +1,244 @@
+// Package aggregate builds the web dashboard (see docs/web-dashboard.md) from
+// the JSON reports of many test runs: the results are stored in a SQLite
+// database, which is then rendered into Hugo data and content files.
+package aggregate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/gateway-conformance/tooling/report"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS TestRun (
+	implementation_id TEXT,
+	version TEXT,
+	time DATETIME,
+	job_url TEXT,
+
+	PRIMARY KEY (implementation_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS TestResult (
+	test_run_implementation_id TEXT,
+	test_run_version TEXT,
+
+	full_name TEXT,
+	name TEXT,
+	outcome TEXT CHECK(outcome IN ('pass', 'fail', 'skip')),
+
+	parent_test_full_name TEXT,
+
+	PRIMARY KEY (test_run_implementation_id, test_run_version, full_name),
+
+	-- parent hierarchy
+	FOREIGN KEY (test_run_implementation_id, test_run_version, parent_test_full_name)
+		REFERENCES TestResult (test_run_implementation_id, test_run_version, full_name),
+
+	-- test run
+	FOREIGN KEY (test_run_implementation_id, test_run_version)
+		REFERENCES TestRun (implementation_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS TestMetadata (
+	test_run_implementation_id TEXT,
+	test_run_version TEXT,
+	test_full_name TEXT,
+
+	key TEXT,
+	value JSON,
+
+	PRIMARY KEY (test_run_implementation_id, test_run_version, test_full_name, key),
+
+	-- test run
+	FOREIGN KEY (test_run_implementation_id, test_run_version)
+		REFERENCES TestRun (implementation_id, version),
+
+	-- test result
+	FOREIGN KEY (test_run_implementation_id, test_run_version, test_full_name)
+		REFERENCES TestResult (test_run_implementation_id, test_run_version, full_name)
+);
+
+CREATE TABLE IF NOT EXISTS TestLog (
+	test_run_implementation_id TEXT,
+	test_run_version TEXT,
+	test_full_name TEXT,
+
+	stdout TEXT,
+
+	-- test run
+	FOREIGN KEY (test_run_implementation_id, test_run_version)
+		REFERENCES TestRun (implementation_id, version),
+
+	-- test result
+	FOREIGN KEY (test_run_implementation_id, test_run_version, test_full_name)
+		REFERENCES TestResult (test_run_implementation_id, test_run_version, full_name)
+);
+
+CREATE TABLE IF NOT EXISTS TestSpecs (
+	test_run_implementation_id TEXT,
+	test_run_version TEXT,
+	test_full_name TEXT,
+
+	spec_url TEXT,
+
+	PRIMARY KEY (test_run_implementation_id, test_run_version, test_full_name, spec_url),
+
+	-- test run
+	FOREIGN KEY (test_run_implementation_id, test_run_version)
+		REFERENCES TestRun (implementation_id, version),
+
+	-- test result
+	FOREIGN KEY (test_run_implementation_id, test_run_version, test_full_name)
+		REFERENCES TestResult (test_run_implementation_id, test_run_version, full_name)
+);
+`
+
+// Open opens (or creates) the SQLite database at path and makes sure it
+// contains the dashboard schema.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite supports a single writer.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the schema: %w", err)
+	}
+
+	return db, nil
+}
+
+// ImplementationID returns the implementation a report file belongs to: its
+// base name without extensions, e.g. "kubo" for "artifacts/kubo.json".
+func ImplementationID(file string) string {
+	id, _, _ := strings.Cut(filepath.Base(file), ".")
+	return id
+}
+
+// Ingest stores the results of a test run of the given implementation. The
+// run is identified by the implementation and the version of the test suite,
+// results of a previous run with the same version are replaced.
+func Ingest(db *sql.DB, implementationID string, r *report.Report) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	meta := r.RunMeta()
+	version, _ := meta["version"].(string)
+	if version == "" {
+		version = "unknown"
+	}
+
+	var jobURL, runTime any
+	if v, _ := meta["job_url"].(string); v != "" {
+		jobURL = v
+	}
+	if test := r.Test("TestMetadata"); test != nil && !test.Time.IsZero() {
+		runTime = test.Time.Format(time.RFC3339Nano)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO TestRun (implementation_id, version, time, job_url)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (implementation_id, version) DO UPDATE SET
+			time = excluded.time,
+			job_url = excluded.job_url
+	`, implementationID, version, runTime, jobURL); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"TestSpecs", "TestLog", "TestMetadata", "TestResult"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE test_run_implementation_id = ? AND test_run_version = ?`, implementationID, version); err != nil {
+			return err
+		}
+	}
+
+	// Insert parents before their subtests.
+	tests := make([]*report.Test, 0, len(r.Tests))
+	for _, test := range r.Tests {
+		if test.Name != "TestMetadata" {
+			tests = append(tests, test)
+		}
+	}
+	sort.Slice(tests, func(i, j int) bool { return tests[i].Name < tests[j].Name })
+
+	for _, test := range tests {
+		var parent any
+		if p := test.Parent(); p != "" {
+			parent = p
+		}
+
+		// A test without an outcome was interrupted (timeout, panic, ...)
+		// which is reported as a failure by `go test`.
+		outcome := test.Outcome
+		if outcome == report.Unknown {
+			outcome = report.Fail
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO TestResult (test_run_implementation_id, test_run_version, full_name, name, outcome, parent_test_full_name)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, implementationID, version, test.Name, test.Path[len(test.Path)-1], outcome, parent); err != nil {
+			return fmt.Errorf("failed to insert %s: %w", test.Name, err)
+		}
+
+		keys := make([]string, 0, len(test.Meta))
+		for k := range test.Meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value, err := json.Marshal(test.Meta[key])
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`
+				INSERT INTO TestMetadata (test_run_implementation_id, test_run_version, test_full_name, key, value)
+				VALUES (?, ?, ?, ?, ?)
+			`, implementationID, version, test.Name, key, string(value)); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO TestLog (test_run_implementation_id, test_run_version, test_full_name, stdout)
+			VALUES (?, ?, ?, ?)
+		`, implementationID, version, test.Name, test.Output); err != nil {
+			return err
+		}
+
+		specs, _ := test.Meta["specs"].([]any)
+		for _, spec := range specs {
+			specURL, ok := spec.(string)
+			if !ok {
+				continue
+			}
+			if !strings.HasPrefix(specURL, "http") {
+				specURL = "https://" + specURL
+			}
+
+			if _, err := tx.Exec(`
+				INSERT OR IGNORE INTO TestSpecs (test_run_implementation_id, test_run_version, test_full_name, spec_url)
+				VALUES (?, ?, ?, ?)
+			`, implementationID, version, test.Name, specURL); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,167 @@
+package aggregate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const events = `{"Time":"2026-01-01T00:00:00Z","Action":"start","Package":"Gateway Tests"}
+{"Time":"2026-01-01T00:00:00Z","Action":"run","Package":"Gateway Tests","Test":"TestMetadata"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestMetadata","Output":"    metadata.go:21: --- META: {\"version\":\"v1.0.0\"}\n"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestMetadata","Output":"    metadata.go:22: --- META: {\"job_url\":\"https://github.com/ipfs/kubo/actions/runs/42\"}\n"}
+{"Time":"2026-01-01T00:00:00Z","Action":"pass","Package":"Gateway Tests","Test":"TestMetadata","Elapsed":0}
+{"Time":"2026-01-01T00:00:00Z","Action":"run","Package":"Gateway Tests","Test":"TestTar"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestTar","Output":"    path_gateway_tar.go:14: --- META: {\"group\":\"Tar\"}\n"}
+{"Time":"2026-01-01T00:00:00Z","Action":"run","Package":"Gateway Tests","Test":"TestTar/GET_TAR"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestTar/GET_TAR","Output":"    test.go:82: --- META: {\"specs\":[\"https://specs.ipfs.tech/http-gateways/path-gateway/#format-request-query-parameter\"]}\n"}
+{"Time":"2026-01-01T00:00:00Z","Action":"run","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Status_code"}
+{"Time":"2026-01-01T00:00:01Z","Action":"fail","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Status_code","Elapsed":0.5}
+{"Time":"2026-01-01T00:00:01Z","Action":"run","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Body"}
+{"Time":"2026-01-01T00:00:01Z","Action":"pass","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Body","Elapsed":0}
+{"Time":"2026-01-01T00:00:01Z","Action":"fail","Package":"Gateway Tests","Test":"TestTar/GET_TAR","Elapsed":0.5}
+{"Time":"2026-01-01T00:00:01Z","Action":"fail","Package":"Gateway Tests","Test":"TestTar","Elapsed":0.6}
+{"Time":"2026-01-01T00:00:01Z","Action":"run","Package":"Gateway Tests","Test":"TestTarX"}
+{"Time":"2026-01-01T00:00:01Z","Action":"pass","Package":"Gateway Tests","Test":"TestTarX","Elapsed":0}
+{"Time":"2026-01-01T00:00:01Z","Action":"suite_fail","Package":"Gateway Tests","Elapsed":1.2}
+`
+
+func parse(t *testing.T) *report.Report {
+	r, err := report.Parse(strings.NewReader(events))
+	require.NoError(t, err)
+	return r
+}
+
+func readJSON(t *testing.T, path string, v any) {
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, v))
+}
+
+func TestIngestAndGenerate(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(filepath.Join(dir, "aggregates.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Ingesting the same run twice replaces it.
+	require.NoError(t, Ingest(db, "kubo", parse(t)))
+	require.NoError(t, Ingest(db, "kubo", parse(t)))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM TestResult WHERE test_run_version = 'v1.0.0'`).Scan(&count))
+	assert.Equal(t, 5, count)
+
+	var specURL string
+	require.NoError(t, db.QueryRow(`SELECT spec_url FROM TestSpecs WHERE test_full_name = 'TestTar/GET_TAR'`).Scan(&specURL))
+	assert.Equal(t, "https://specs.ipfs.tech/http-gateways/path-gateway/#format-request-query-parameter", specURL)
+
+	www := filepath.Join(dir, "www")
+	require.NoError(t, Generate(context.Background(), db, www, func(ctx context.Context, jobURL string) (map[string]any, error) {
+		return map[string]any{"head_sha": "abc"}, nil
+	}))
+
+	var runs map[string]map[string]map[string]any
+	readJSON(t, filepath.Join(www, "data/testruns.json"), &runs)
+	assert.Equal(t, "https://github.com/ipfs/kubo/actions/runs/42", runs["kubo"]["v1.0.0"]["job_url"])
+	assert.Equal(t, "abc", runs["kubo"]["v1.0.0"]["head_sha"])
+
+	var results map[string]testResult
+	readJSON(t, filepath.Join(www, "data/testresults/kubo/v1.0.0.json"), &results)
+	// TestTarX is not a subtest of TestTar.
+	assert.Equal(t, 2, results["TestTar"].TotalLeaves)
+	assert.Equal(t, 1, results["TestTar"].FailedLeaves)
+	assert.Equal(t, 1, results["TestTarX"].TotalLeaves)
+	assert.Equal(t, "test-tar/get-tar/status-code", results["TestTar/GET_TAR/Status_code"].Slug)
+
+	var groups map[string]map[string]testGroup
+	readJSON(t, filepath.Join(www, "data/testgroups.json"), &groups)
+	assert.Contains(t, groups["null"], "TestTar")
+	assert.Contains(t, groups["TestTar/GET_TAR"], "TestTar/GET_TAR/Body")
+
+	var specsGroups map[string]map[string]testGroup
+	readJSON(t, filepath.Join(www, "data/specsgroups.json"), &specsGroups)
+	assert.Contains(t, specsGroups["https://specs.ipfs.tech/http-gateways/path-gateway"], "TestTar/GET_TAR")
+
+	page, err := os.ReadFile(filepath.Join(www, "content/specs/http-gateways/path-gateway/_index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "hashes:\n    - format-request-query-parameter\n")
+
+	page, err = os.ReadFile(filepath.Join(www, "content/tests/test-tar/_index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "groups:\n    - Tar\n")
+
+	page, err = os.ReadFile(filepath.Join(www, "content/results/kubo/v1.0.0/test-tar/get-tar/status-code/_index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "outcome: fail\n")
+}
+
+func TestUpdateFrontmatterKeepsContent(t *testing.T) {
+	g := &generator{www: t.TempDir()}
+	path := filepath.Join(g.www, "content/current.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("---\ntitle: Current\n---\n\nSome content\n"), 0644))
+
+	require.NoError(t, g.updateFrontmatter("content/current.md", func(data map[string]any) {
+		data["published"] = true
+	}))
+
+	page, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "---\npublished: true\ntitle: Current\n---\n\nSome content\n", string(page))
+}
+
+func TestSlugify(t *testing.T) {
+	for name, slug := range map[string]string{
+		"TestGatewayCache":                                  "test-gateway-cache",
+		"TestGatewayCache/GET_for_%2Fipfs%2F_file":          "test-gateway-cache/get-for-ipfs-file",
+		"TestPlainCodec/GET_plain_JSON_codec_with_Accept_é": "test-plain-codec/get-plain-json-codec-with-accept-e",
+	} {
+		assert.Equal(t, slug, slugifyTestName(name), name)
+	}
+	assert.Equal(t, "https-specs-ipfs-tech-http-gateways-path-gateway-", slugify("https://specs.ipfs.tech/http-gateways/path-gateway/"))
+}
+
+func TestSpecParentAndName(t *testing.T) {
+	for _, tc := range []struct {
+		spec, parent, name string
+		isHashed           bool
+	}{
+		{"https://specs.ipfs.tech/http-gateways/path-gateway/#if-none-match", "https://specs.ipfs.tech/http-gateways/path-gateway", "if-none-match", true},
+		{"https://specs.ipfs.tech/http-gateways/path-gateway/", "https://specs.ipfs.tech/http-gateways", "path-gateway", false},
+		{"https://specs.ipfs.tech/http-gateways", "null", "http-gateways", false},
+	} {
+		parent, err := specParent(tc.spec)
+		require.NoError(t, err)
+		assert.Equal(t, tc.parent, parent)
+
+		name, isHashed, err := specName(tc.spec)
+		require.NoError(t, err)
+		assert.Equal(t, tc.name, name)
+		assert.Equal(t, tc.isHashed, isHashed)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	r := parse(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTable(&buf, []Run{{Name: "kubo", Report: r}, {Name: "boxo", Report: r}}))
+
+	assert.Equal(t, `| gateway | kubo | boxo |
+| ------: | :--- | :--- |
+| version | [v1.0.0](https://github.com/ipfs/kubo/actions/runs/42) | [v1.0.0](https://github.com/ipfs/kubo/actions/runs/42) |
+| **Tar** |  |  |
+| Tar | :red_circle: (1 / 2) | :red_circle: (1 / 2) |
+| **Other** |  |  |
+| TarX | :green_circle: (1 / 1) | :green_circle: (1 / 1) |
+`, buf.String())
+}
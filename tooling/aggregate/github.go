@@ -0,0 +1,60 @@
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+var githubRunRegexp = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/actions/runs/(\d+)`)
+
+// GitHubRunDetails returns a RunDetailsFunc fetching the creation time,
+// commit, branch and start time of GitHub Actions runs. Other job URLs have no
+// details. The token is optional, it raises the GitHub API rate limit.
+func GitHubRunDetails(token string) RunDetailsFunc {
+	return func(ctx context.Context, jobURL string) (map[string]any, error) {
+		m := githubRunRegexp.FindStringSubmatch(jobURL)
+		if m == nil {
+			return nil, nil
+		}
+
+		apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/runs/%s", m[1], m[2], m[3])
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		if token != "" {
+			req.Header.Set("Authorization", "token "+token)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", res.Status)
+		}
+
+		var run struct {
+			CreatedAt    string `json:"created_at"`
+			HeadSHA      string `json:"head_sha"`
+			HeadBranch   string `json:"head_branch"`
+			RunStartedAt string `json:"run_started_at"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&run); err != nil {
+			return nil, err
+		}
+
+		return map[string]any{
+			"created_at":     run.CreatedAt,
+			"head_sha":       run.HeadSHA,
+			"head_branch":    run.HeadBranch,
+			"run_started_at": run.RunStartedAt,
+		}, nil
+	}
+}
@@ -0,0 +1,700 @@
+package aggregate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RunDetailsFunc returns extra details about the job that produced a test
+// run (commit, branch, start time, ...), added to data/testruns.json.
+type RunDetailsFunc func(ctx context.Context, jobURL string) (map[string]any, error)
+
+type generator struct {
+	db   *sql.DB
+	www  string
+	ctx  context.Context
+	runs RunDetailsFunc
+}
+
+// Generate writes the Hugo data (www/data/*.json) and content
+// (www/content/{specs,tests,results}) of the dashboard from the database.
+// runDetails may be nil.
+func Generate(ctx context.Context, db *sql.DB, www string, runDetails RunDetailsFunc) error {
+	g := &generator{db: db, www: www, ctx: ctx, runs: runDetails}
+
+	runs, err := g.testRuns()
+	if err != nil {
+		return err
+	}
+
+	testGroups, err := g.testGroups()
+	if err != nil {
+		return err
+	}
+
+	if err := g.specs(testGroups); err != nil {
+		return err
+	}
+
+	if err := g.testLogs(); err != nil {
+		return err
+	}
+
+	for _, run := range runs {
+		if err := g.testResults(run.id, run.version); err != nil {
+			return err
+		}
+	}
+
+	if err := g.testsTaxonomy(); err != nil {
+		return err
+	}
+
+	return g.resultsTaxonomy()
+}
+
+type testRun struct {
+	id, version string
+}
+
+func (g *generator) testRuns() ([]testRun, error) {
+	rows, err := g.db.QueryContext(g.ctx, `
+		SELECT implementation_id, version, time, job_url
+		FROM TestRun
+		ORDER BY implementation_id, version, time
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []testRun
+	data := map[string]map[string]map[string]any{}
+	for rows.Next() {
+		var id, version string
+		var runTime, jobURL sql.NullString
+		if err := rows.Scan(&id, &version, &runTime, &jobURL); err != nil {
+			return nil, err
+		}
+
+		run := map[string]any{
+			"time":    nullable(runTime),
+			"job_url": nullable(jobURL),
+		}
+		if g.runs != nil && jobURL.Valid {
+			details, err := g.runs(g.ctx, jobURL.String)
+			if err != nil {
+				log.Printf("Error fetching %s details: %v", jobURL.String, err)
+			}
+			for k, v := range details {
+				run[k] = v
+			}
+		}
+
+		if data[id] == nil {
+			data[id] = map[string]map[string]any{}
+		}
+		data[id][version] = run
+		runs = append(runs, testRun{id: id, version: version})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return runs, g.writeJSON("data/testruns.json", data)
+}
+
+type testGroup struct {
+	Versions []string `json:"versions"`
+	Name     string   `json:"name"`
+	FullName string   `json:"full_name"`
+	Slug     string   `json:"slug"`
+}
+
+// testGroups writes the tests hierarchy, indexed by parent ("null" for the
+// top-level tests), and returns the tests indexed by full name.
+func (g *generator) testGroups() (map[string]testGroup, error) {
+	rows, err := g.db.QueryContext(g.ctx, `
+		SELECT
+			full_name,
+			name,
+			parent_test_full_name,
+			GROUP_CONCAT(DISTINCT test_run_version) AS versions
+		FROM TestResult
+		GROUP BY full_name, name
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := map[string]map[string]testGroup{}
+	flat := map[string]testGroup{}
+	for rows.Next() {
+		var fullName, name string
+		var parent, versions sql.NullString
+		if err := rows.Scan(&fullName, &name, &parent, &versions); err != nil {
+			return nil, err
+		}
+
+		parentName := "null"
+		if parent.Valid {
+			parentName = parent.String
+		}
+		if groups[parentName] == nil {
+			groups[parentName] = map[string]testGroup{}
+		}
+
+		group := testGroup{
+			Versions: splitVersions(versions),
+			Name:     name,
+			FullName: fullName,
+			Slug:     slugifyTestName(fullName),
+		}
+		groups[parentName][fullName] = group
+		flat[fullName] = group
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return flat, g.writeJSON("data/testgroups.json", groups)
+}
+
+type spec struct {
+	Versions     []string `json:"versions"`
+	SpecFullName string   `json:"spec_full_name"`
+	Slug         string   `json:"slug"`
+	Name         string   `json:"name"`
+	IsHashed     bool     `json:"isHashed"`
+}
+
+// specs writes the specs hierarchy (a spec URL, its parent URLs and its
+// sections), its content pages, and the tests covering each spec.
+func (g *generator) specs(testGroups map[string]testGroup) error {
+	rows, err := g.db.QueryContext(g.ctx, `
+		SELECT
+			spec_url AS full_name,
+			GROUP_CONCAT(DISTINCT test_run_version) AS versions
+		FROM TestSpecs
+		GROUP BY full_name
+		ORDER BY full_name
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	specs := map[string]map[string]spec{}
+	var flat []string
+	for rows.Next() {
+		var fullName string
+		var versions sql.NullString
+		if err := rows.Scan(&fullName, &versions); err != nil {
+			return err
+		}
+
+		for current := fullName; current != "null"; {
+			parent, err := specParent(current)
+			if err != nil {
+				return err
+			}
+			name, isHashed, err := specName(current)
+			if err != nil {
+				return err
+			}
+
+			if specs[parent] == nil {
+				specs[parent] = map[string]spec{}
+			}
+			if !slices.Contains(flat, current) {
+				flat = append(flat, current)
+			}
+
+			// Parents are covered by the versions covering any child.
+			specs[parent][current] = spec{
+				Versions:     mergeVersions(specs[parent][current].Versions, splitVersions(versions)),
+				SpecFullName: current,
+				Slug:         slugify(current),
+				Name:         name,
+				IsHashed:     isHashed,
+			}
+
+			current = parent
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if err := g.writeJSON("data/specs.json", specs); err != nil {
+		return err
+	}
+
+	if err := g.specsPages(specs, "null", nil); err != nil {
+		return err
+	}
+
+	// List the tests covering every spec, including the tests covering
+	// its sections.
+	specsGroups := map[string]map[string]testGroup{}
+	sort.Strings(flat)
+	for _, fullName := range flat {
+		rows, err := g.db.QueryContext(g.ctx, `
+			SELECT DISTINCT test_full_name
+			FROM TestSpecs
+			WHERE substr(spec_url, 1, length(?1)) = ?1
+			ORDER BY test_full_name
+		`, fullName)
+		if err != nil {
+			return err
+		}
+
+		tests := map[string]testGroup{}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return err
+			}
+			tests[name] = testGroups[name]
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		specsGroups[fullName] = tests
+	}
+
+	return g.writeJSON("data/specsgroups.json", specsGroups)
+}
+
+// specsPages writes a content page for every spec, following the URLs
+// structure. Sections (URL fragments) are listed in their spec page hashes.
+func (g *generator) specsPages(specs map[string]map[string]spec, current string, path []string) error {
+	children := specs[current]
+	keys := make([]string, 0, len(children))
+	for k := range children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := children[key]
+
+		if s.IsHashed {
+			err := g.updateFrontmatter(filepath.Join("content/specs", filepath.Join(path...), "_index.md"), func(data map[string]any) {
+				hashes, _ := data["hashes"].([]any)
+				if !slices.Contains(hashes, any(s.Name)) {
+					hashes = append(hashes, s.Name)
+				}
+				data["hashes"] = hashes
+			})
+			if err != nil {
+				return err
+			}
+			// Sections have no children.
+			continue
+		}
+
+		childPath := append(slices.Clone(path), s.Name)
+		err := g.updateFrontmatter(filepath.Join("content/specs", filepath.Join(childPath...), "_index.md"), func(data map[string]any) {
+			data["versions"] = s.Versions
+			data["spec_full_name"] = s.SpecFullName
+			data["slug"] = s.Slug
+			data["name"] = s.Name
+			data["isHashed"] = s.IsHashed
+			data["title"] = s.Name
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := g.specsPages(specs, key, childPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) testLogs() error {
+	rows, err := g.db.QueryContext(g.ctx, `
+		SELECT
+			test_run_implementation_id,
+			test_run_version,
+			test_full_name,
+			stdout
+		FROM TestLog
+		ORDER BY test_full_name
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	logs := map[string]map[string]map[string]string{}
+	for rows.Next() {
+		var id, version, fullName string
+		var stdout sql.NullString
+		if err := rows.Scan(&id, &version, &fullName, &stdout); err != nil {
+			return err
+		}
+
+		if logs[id] == nil {
+			logs[id] = map[string]map[string]string{}
+		}
+		if logs[id][version] == nil {
+			logs[id][version] = map[string]string{}
+		}
+		logs[id][version][fullName] = stdout.String
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return g.writeJSON("data/testlogs.json", logs)
+}
+
+type testResult struct {
+	FullName      string  `json:"full_name"`
+	Name          string  `json:"name"`
+	Parent        *string `json:"parent_test_full_name"`
+	PassedLeaves  int     `json:"passed_leave"`
+	FailedLeaves  int     `json:"failed_leaves"`
+	SkippedLeaves int     `json:"skipped_leaves"`
+	TotalLeaves   int     `json:"total_leaves"`
+	Slug          string  `json:"slug"`
+}
+
+// testResults writes the outcomes of the leaf tests under every test of a
+// run.
+func (g *generator) testResults(id, version string) error {
+	rows, err := g.db.QueryContext(g.ctx, `
+		WITH LeafTests AS (
+			-- Identify leaf tests (tests without a descendant)
+			SELECT full_name, outcome
+			FROM TestResult tr1
+			WHERE test_run_implementation_id = ?1 AND test_run_version = ?2
+			AND NOT EXISTS (
+				SELECT 1
+				FROM TestResult tr2
+				WHERE tr2.test_run_implementation_id = tr1.test_run_implementation_id
+					AND tr2.test_run_version = tr1.test_run_version
+					AND tr2.parent_test_full_name = tr1.full_name
+			)
+		)
+
+		SELECT
+			tr.full_name,
+			tr.name,
+			tr.parent_test_full_name,
+			COUNT(CASE WHEN lt.outcome = 'pass' THEN 1 ELSE NULL END) AS passed_leave,
+			COUNT(CASE WHEN lt.outcome = 'fail' THEN 1 ELSE NULL END) AS failed_leaves,
+			COUNT(CASE WHEN lt.outcome = 'skip' THEN 1 ELSE NULL END) AS skipped_leaves,
+			COUNT(lt.full_name) AS total_leaves
+		FROM TestResult tr
+		LEFT JOIN LeafTests lt
+			ON lt.full_name = tr.full_name
+			OR substr(lt.full_name, 1, length(tr.full_name) + 1) = tr.full_name || '/'
+		WHERE tr.test_run_implementation_id = ?1 AND tr.test_run_version = ?2
+		GROUP BY tr.full_name
+		ORDER BY tr.full_name
+	`, id, version)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	results := map[string]testResult{}
+	for rows.Next() {
+		var r testResult
+		var parent sql.NullString
+		if err := rows.Scan(&r.FullName, &r.Name, &parent, &r.PassedLeaves, &r.FailedLeaves, &r.SkippedLeaves, &r.TotalLeaves); err != nil {
+			return err
+		}
+		if parent.Valid {
+			r.Parent = &parent.String
+		}
+		r.Slug = slugifyTestName(r.FullName)
+		results[r.FullName] = r
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return g.writeJSON(filepath.Join("data/testresults", id, version+".json"), results)
+}
+
+// testsTaxonomy writes a content page per test, with the versions it ran on
+// and its metadata as taxonomies (e.g. group: X becomes groups: [X]).
+func (g *generator) testsTaxonomy() error {
+	rows, err := g.db.QueryContext(g.ctx, `
+		SELECT DISTINCT
+			tr.full_name,
+			tr.name,
+			tr.test_run_version,
+			tm.key,
+			tm.value
+		FROM TestResult tr
+		LEFT JOIN TestMetadata tm
+			ON tm.test_run_implementation_id = tr.test_run_implementation_id
+			AND tm.test_run_version = tr.test_run_version
+			AND tm.test_full_name = tr.full_name
+		ORDER BY full_name
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var names []string
+	tests := map[string]map[string]any{}
+	for rows.Next() {
+		var fullName, name, version string
+		var key, value sql.NullString
+		if err := rows.Scan(&fullName, &name, &version, &key, &value); err != nil {
+			return err
+		}
+
+		test, ok := tests[fullName]
+		if !ok {
+			test = map[string]any{
+				"slug":      slugifyTestName(fullName),
+				"name":      unescape(name),
+				"full_name": fullName,
+				"versions":  []any{},
+			}
+			tests[fullName] = test
+			names = append(names, fullName)
+		}
+		test["versions"] = addUnique(test["versions"].([]any), version)
+
+		if key.Valid {
+			var v any
+			if err := json.Unmarshal([]byte(value.String), &v); err != nil {
+				return err
+			}
+
+			// Taxonomies are plural, ipip => ipips.
+			k := key.String + "s"
+			values, _ := test[k].([]any)
+			if list, ok := v.([]any); ok {
+				values = addUnique(values, list...)
+			} else {
+				values = addUnique(values, v)
+			}
+			test[k] = values
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, fullName := range names {
+		test := tests[fullName]
+		err := g.updateFrontmatter(filepath.Join("content/tests", test["slug"].(string), "_index.md"), func(data map[string]any) {
+			for k, v := range test {
+				data[k] = v
+			}
+			data["title"] = test["name"]
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resultsTaxonomy writes a content page per implementation, per version of
+// the test suite and per test result.
+func (g *generator) resultsTaxonomy() error {
+	rows, err := g.db.QueryContext(g.ctx, `
+		SELECT
+			test_run_implementation_id,
+			test_run_version,
+			full_name,
+			name,
+			outcome
+		FROM TestResult
+		ORDER BY test_run_implementation_id, test_run_version, full_name
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	seen := map[string]bool{}
+	for rows.Next() {
+		var id, version, fullName, name, outcome string
+		if err := rows.Scan(&id, &version, &fullName, &name, &outcome); err != nil {
+			return err
+		}
+
+		pages := []struct {
+			path string
+			data map[string]any
+		}{
+			{
+				path: filepath.Join("content/results", id, "_index.md"),
+				data: map[string]any{"implementation_id": id, "title": id},
+			},
+			{
+				path: filepath.Join("content/results", id, version, "_index.md"),
+				data: map[string]any{"implementation_id": id, "version": version, "title": version},
+			},
+			{
+				path: filepath.Join("content/results", id, version, slugifyTestName(fullName), "_index.md"),
+				data: map[string]any{
+					"slug":              slugifyTestName(fullName),
+					"name":              unescape(name),
+					"full_name":         fullName,
+					"outcome":           outcome,
+					"implementation_id": id,
+					"version":           version,
+					"title":             unescape(name),
+				},
+			},
+		}
+
+		for _, page := range pages {
+			if seen[page.path] {
+				continue
+			}
+			seen[page.path] = true
+
+			err := g.updateFrontmatter(page.path, func(data map[string]any) {
+				for k, v := range page.data {
+					data[k] = v
+				}
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return rows.Err()
+}
+
+func (g *generator) writeJSON(path string, data any) error {
+	path = filepath.Join(g.www, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// updateFrontmatter updates the YAML front matter of a content page, creating
+// the page if needed. The content of existing pages is preserved.
+func (g *generator) updateFrontmatter(path string, update func(data map[string]any)) error {
+	path = filepath.Join(g.www, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data := map[string]any{}
+	var content string
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		var frontmatter string
+		frontmatter, content = splitFrontmatter(string(existing))
+		if err := yaml.Unmarshal([]byte(frontmatter), &data); err != nil {
+			return fmt.Errorf("failed to parse the front matter of %s: %w", path, err)
+		}
+		if data == nil {
+			data = map[string]any{}
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	update(data)
+
+	frontmatter, err := yaml.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	b.Write(frontmatter)
+	b.WriteString("---\n")
+	b.WriteString(content)
+	return os.WriteFile(path, b.Bytes(), 0644)
+}
+
+// splitFrontmatter splits a page into its front matter and its content.
+func splitFrontmatter(page string) (frontmatter, content string) {
+	rest, ok := strings.CutPrefix(page, "---\n")
+	if !ok {
+		return "", page
+	}
+
+	if strings.HasPrefix(rest, "---\n") {
+		return "", rest[len("---\n"):]
+	}
+
+	i := strings.Index(rest, "\n---\n")
+	if i < 0 {
+		return "", page
+	}
+	return rest[:i+1], rest[i+len("\n---\n"):]
+}
+
+func splitVersions(versions sql.NullString) []string {
+	if !versions.Valid || versions.String == "" {
+		return []string{}
+	}
+	v := strings.Split(versions.String, ",")
+	sort.Strings(v)
+	return v
+}
+
+func mergeVersions(a, b []string) []string {
+	merged := slices.Clone(a)
+	for _, v := range b {
+		if !slices.Contains(merged, v) {
+			merged = append(merged, v)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+func addUnique(values []any, add ...any) []any {
+	for _, v := range add {
+		if !slices.ContainsFunc(values, func(x any) bool { return fmt.Sprint(x) == fmt.Sprint(v) }) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func nullable(s sql.NullString) any {
+	if !s.Valid {
+		return nil
+	}
+	return s.String
+}
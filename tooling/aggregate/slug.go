@@ -0,0 +1,105 @@
+package aggregate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	accentsRegexp    = regexp.MustCompile(`[\x{0300}-\x{036f}]`)
+	spacesRegexp     = regexp.MustCompile(`\s+`)
+	punctRegexp      = regexp.MustCompile(`[.,()"/]`)
+	nonSlugRegexp    = regexp.MustCompile(`[^a-z0-9 -/]`)
+	underscoreRegexp = regexp.MustCompile(`_+`)
+	dashRegexp       = regexp.MustCompile(`-+`)
+	camelCaseRegexp  = regexp.MustCompile(`([a-z])([A-Z])`)
+)
+
+// slugify turns a name into a string usable in a dashboard URL. Existing
+// dashboard links depend on it, keep it stable.
+func slugify(s string) string {
+	s = norm.NFKD.String(s)
+	s = accentsRegexp.ReplaceAllString(s, "")
+	s = strings.TrimSpace(s)
+	s = strings.ToLower(s)
+	s = spacesRegexp.ReplaceAllString(s, "_")
+	s = punctRegexp.ReplaceAllString(s, "-")
+	s = nonSlugRegexp.ReplaceAllString(s, "-")
+	s = underscoreRegexp.ReplaceAllString(s, "_")
+	return dashRegexp.ReplaceAllString(s, "-")
+}
+
+// slugifyTestName slugifies every part of a test full name, splitting
+// CamelCase words: "TestGatewayCache/GET_for_/ipfs/" becomes
+// "test-gateway-cache/get-for-/ipfs/".
+func slugifyTestName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = slugify(camelCaseRegexp.ReplaceAllString(unescape(part), "$1-$2"))
+	}
+	return strings.Join(parts, "/")
+}
+
+// unescape decodes percent-encoded characters in test names, e.g. %2F for
+// slashes in subtest names.
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// specSegments returns the path segments of a spec URL, its fragment being the
+// last segment.
+func specSegments(u *url.URL) []string {
+	var segments []string
+	for segment := range strings.SplitSeq(u.EscapedPath(), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if u.Fragment != "" {
+		segments = append(segments, u.EscapedFragment())
+	}
+	return segments
+}
+
+// specParent returns the parent of a spec URL, e.g.
+// "https://specs.ipfs.tech/http-gateways" for
+// "https://specs.ipfs.tech/http-gateways/path-gateway/", or "null" for a
+// top-level spec.
+func specParent(spec string) (string, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return "", err
+	}
+
+	segments := specSegments(u)
+	if len(segments) <= 1 {
+		return "null", nil
+	}
+	return fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, strings.Join(segments[:len(segments)-1], "/")), nil
+}
+
+// specName returns the name of a spec URL: its fragment when it has one, or
+// the last segment of its path.
+func specName(spec string) (name string, isHashed bool, err error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return "", false, err
+	}
+
+	if u.Fragment != "" {
+		return u.EscapedFragment(), true, nil
+	}
+
+	segments := specSegments(u)
+	if len(segments) == 0 {
+		return "", false, fmt.Errorf("invalid spec URL: %s", spec)
+	}
+	return segments[len(segments)-1], false, nil
+}
@@ -0,0 +1,169 @@
+package aggregate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/gateway-conformance/tooling/report"
+)
+
+// Run is the report of a test run of an implementation.
+type Run struct {
+	Name   string
+	Report *report.Report
+}
+
+type tableRow struct {
+	group string // "" for tests without a group
+	test  string // "" for the group header
+	specs []string
+}
+
+// WriteTable writes a Markdown table comparing the results of the top-level
+// tests, sorted by group, across test runs (one column per run).
+func WriteTable(w io.Writer, runs []Run) error {
+	// Merge the top-level tests of every run.
+	tests := map[string]*tableRow{}
+	for _, run := range runs {
+		for _, test := range run.Report.Children("") {
+			if test.Name == "TestMetadata" {
+				continue
+			}
+			row, ok := tests[test.Name]
+			if !ok {
+				row = &tableRow{test: test.Name}
+				tests[test.Name] = row
+			}
+			if group := run.Report.Group(test); group != "" {
+				row.group = group
+			}
+			if specs := run.Report.Specs(test); len(specs) > 0 {
+				row.specs = specs
+			}
+		}
+	}
+
+	var rows []tableRow
+	groups := map[string]bool{}
+	for _, row := range tests {
+		if !groups[row.group] {
+			groups[row.group] = true
+			rows = append(rows, tableRow{group: row.group})
+		}
+		rows = append(rows, *row)
+	}
+
+	// Sort by group, then by test, tests without a group come last.
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.group != b.group {
+			if a.group == "" || b.group == "" {
+				return b.group == ""
+			}
+			return a.group < b.group
+		}
+		return a.test < b.test
+	})
+
+	header := []string{"gateway", "version"}
+	for _, row := range rows {
+		header = append(header, tableRowName(row))
+	}
+
+	columns := [][]string{header}
+	for _, run := range runs {
+		meta := run.Report.RunMeta()
+		version, _ := meta["version"].(string)
+		if version == "" {
+			version = "unknown"
+		}
+		if jobURL, _ := meta["job_url"].(string); jobURL != "" {
+			version = fmt.Sprintf("[%s](%s)", version, jobURL)
+		}
+
+		column := []string{run.Name, version}
+		for _, row := range rows {
+			column = append(column, tableCell(run.Report, row))
+		}
+		columns = append(columns, column)
+	}
+
+	var b strings.Builder
+	for i := range header {
+		line := make([]string, len(columns))
+		for j, column := range columns {
+			line[j] = column[i]
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(line, " | "))
+
+		if i == 0 {
+			for j, cell := range line {
+				if j == 0 {
+					line[j] = strings.Repeat("-", max(0, len(cell)-2)) + "-:"
+				} else {
+					line[j] = ":-" + strings.Repeat("-", max(0, len(cell)-2))
+				}
+			}
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(line, " | "))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func tableRowName(row tableRow) string {
+	if row.test == "" {
+		group := row.group
+		if group == "" {
+			group = "Other"
+		}
+		return fmt.Sprintf("**%s**", group)
+	}
+
+	name := strings.TrimPrefix(row.test, "Test")
+	switch len(row.specs) {
+	case 0:
+		return name
+	case 1:
+		return fmt.Sprintf("[%s](%s)", name, specURL(row.specs[0]))
+	default:
+		links := make([]string, len(row.specs))
+		for i, spec := range row.specs {
+			links[i] = fmt.Sprintf("[%d](%s)", i, specURL(spec))
+		}
+		return fmt.Sprintf("%s (%s)", name, strings.Join(links, ", "))
+	}
+}
+
+func tableCell(r *report.Report, row tableRow) string {
+	if row.test == "" {
+		return ""
+	}
+
+	test := r.Test(row.test)
+	if test == nil {
+		return ""
+	}
+
+	s := r.SummaryOf(test)
+	switch {
+	case s.Failed > 0:
+		return fmt.Sprintf(":red_circle: (%d / %d)", s.Passed, s.Total)
+	case s.Skipped > 0:
+		return ":yellow_circle: (skipped)"
+	case s.Passed > 0:
+		return fmt.Sprintf(":green_circle: (%d / %d)", s.Passed, s.Total)
+	default:
+		return ""
+	}
+}
+
+func specURL(spec string) string {
+	if strings.HasPrefix(spec, "http") {
+		return spec
+	}
+	return "https://" + spec
+}
@@ -26,12 +26,17 @@ func (n *FixtureNode) Cid() cid.Cid {
 }
 
 // DNSSafeCidV1 returns the CID as a DNS-safe CIDv1 string suitable for use
-// in subdomain gateway hostnames. If the underlying CID is v0, it is
-// converted to v1 first. The encoding is chosen based on the codec:
-// libp2p-key uses base36 (to fit Ed25519 keys within the 63-char DNS label
-// limit), everything else uses base32.
+// in subdomain gateway hostnames, see DNSSafeCidV1.
 func (n *FixtureNode) DNSSafeCidV1() string {
-	c := n.Cid()
+	return DNSSafeCidV1(n.Cid())
+}
+
+// DNSSafeCidV1 returns the CID as a DNS-safe CIDv1 string suitable for use
+// in subdomain gateway hostnames. If the CID is v0, it is converted to v1
+// first. The encoding is chosen based on the codec: libp2p-key uses base36
+// (to fit Ed25519 keys within the 63-char DNS label limit), everything else
+// uses base32.
+func DNSSafeCidV1(c cid.Cid) string {
 	if c.Version() == 0 {
 		c = cid.NewCidV1(c.Type(), c.Hash())
 	}
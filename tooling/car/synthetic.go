@@ -0,0 +1,285 @@
+package car
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ipfs/boxo/blockservice"
+	chunk "github.com/ipfs/boxo/chunker"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/boxo/ipld/unixfs/hamt"
+	"github.com/ipfs/boxo/ipld/unixfs/importer/balanced"
+	"github.com/ipfs/boxo/ipld/unixfs/importer/helpers"
+	"github.com/ipfs/boxo/ipld/unixfs/importer/trickle"
+	"github.com/ipfs/gateway-conformance/tooling"
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/ipld/go-car/v2/blockstore"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Layout is the shape of the DAG of a synthetic UnixFS file.
+type Layout string
+
+const (
+	LayoutBalanced Layout = "balanced"
+	LayoutTrickle  Layout = "trickle"
+)
+
+// cidBuilder is used for every block of the synthetic fixtures: CIDv1,
+// sha2-256, dag-pb or raw.
+var cidBuilder = cid.V1Builder{Codec: cid.DagProtobuf, MhType: mh.SHA2_256}
+
+// SyntheticSource builds a UnixFS DAG deterministically, the same parameters
+// always give the same root CID.
+type SyntheticSource interface {
+	Build(ctx context.Context, dserv format.DAGService) (format.Node, error)
+	// ContentSize is the number of bytes of file data in the DAG.
+	ContentSize() int64
+}
+
+// SyntheticFile is a UnixFS file of Size bytes of pseudo-random content
+// derived from the Seed.
+type SyntheticFile struct {
+	Size int64
+	Seed uint64
+	// Chunker is a boxo chunker spec, e.g. "size-1048576" or
+	// "rabin-65536-131072-262144", the default is "size-262144".
+	Chunker   string
+	Layout    Layout
+	RawLeaves bool
+}
+
+var _ SyntheticSource = SyntheticFile{}
+
+func (f SyntheticFile) ContentSize() int64 {
+	return f.Size
+}
+
+// Reader returns the content of the file.
+func (f SyntheticFile) Reader() io.Reader {
+	return io.LimitReader(seededReader(f.Seed), f.Size)
+}
+
+func (f SyntheticFile) Build(ctx context.Context, dserv format.DAGService) (format.Node, error) {
+	return buildFile(f.Reader(), f.Chunker, f.Layout, f.RawLeaves, dserv)
+}
+
+// SyntheticDirectory is a HAMT sharded UnixFS directory of Entries files,
+// named "0.bin", "1.bin", etc., of EntrySize bytes each. The content of the
+// files is read in order from a single stream derived from the Seed.
+type SyntheticDirectory struct {
+	Entries   int
+	EntrySize int64
+	Seed      uint64
+}
+
+var _ SyntheticSource = SyntheticDirectory{}
+
+func (d SyntheticDirectory) ContentSize() int64 {
+	return int64(d.Entries) * d.EntrySize
+}
+
+func (d SyntheticDirectory) Build(ctx context.Context, dserv format.DAGService) (format.Node, error) {
+	shard, err := hamt.NewShard(dserv, 256)
+	if err != nil {
+		return nil, err
+	}
+	shard.SetCidBuilder(cidBuilder)
+
+	content := seededReader(d.Seed)
+	for i := range d.Entries {
+		node, err := buildFile(io.LimitReader(content, d.EntrySize), "", LayoutBalanced, true, dserv)
+		if err != nil {
+			return nil, err
+		}
+		err = shard.Set(ctx, fmt.Sprintf("%d.bin", i), node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return shard.Node()
+}
+
+func seededReader(seed uint64) io.Reader {
+	var s [32]byte
+	binary.LittleEndian.PutUint64(s[:], seed)
+	return rand.NewChaCha8(s)
+}
+
+func buildFile(r io.Reader, chunker string, layout Layout, rawLeaves bool, dserv format.DAGService) (format.Node, error) {
+	spl, err := chunk.FromString(r, chunker)
+	if err != nil {
+		return nil, err
+	}
+
+	params := helpers.DagBuilderParams{
+		Maxlinks:   helpers.DefaultLinksPerBlock,
+		RawLeaves:  rawLeaves,
+		CidBuilder: cidBuilder,
+		Dagserv:    dserv,
+	}
+	db, err := params.New(spl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch layout {
+	case LayoutTrickle:
+		return trickle.Layout(db)
+	case LayoutBalanced, "":
+		return balanced.Layout(db)
+	default:
+		return nil, fmt.Errorf("unknown layout %q", layout)
+	}
+}
+
+// SyntheticFixture is a named synthetic source, written to <Name>.car.
+type SyntheticFixture struct {
+	Name   string
+	Source SyntheticSource
+}
+
+// SyntheticFixtures are generated by `extract-fixtures --synthetic` and
+// loaded in tests with MustOpenSyntheticCar.
+var SyntheticFixtures = []SyntheticFixture{
+	{Name: "file-1MiB-balanced-raw", Source: SyntheticFile{Size: 1 << 20, Seed: 1, Layout: LayoutBalanced, RawLeaves: true}},
+	{Name: "file-1MiB-trickle-dagpb", Source: SyntheticFile{Size: 1 << 20, Seed: 2, Chunker: "size-65536", Layout: LayoutTrickle}},
+	{Name: "file-16MiB-balanced-raw", Source: SyntheticFile{Size: 16 << 20, Seed: 3, Layout: LayoutBalanced, RawLeaves: true}},
+	// 4096 leaves, more than the links of a node, give a tree of 3 levels.
+	{Name: "file-16MiB-balanced-raw-4KiB-chunks", Source: SyntheticFile{Size: 16 << 20, Seed: 4, Chunker: "size-4096", Layout: LayoutBalanced, RawLeaves: true}},
+	{Name: "file-16MiB-trickle-raw-rabin", Source: SyntheticFile{Size: 16 << 20, Seed: 5, Chunker: "rabin-65536-262144-1048576", Layout: LayoutTrickle, RawLeaves: true}},
+	{Name: "file-64MiB-balanced-raw-1MiB-chunks", Source: SyntheticFile{Size: 64 << 20, Seed: 6, Chunker: "size-1048576", Layout: LayoutBalanced, RawLeaves: true}},
+	{Name: "file-1GiB-balanced-raw", Source: SyntheticFile{Size: 1 << 30, Seed: 7, Chunker: "size-1048576", Layout: LayoutBalanced, RawLeaves: true}},
+	// Past 4GiB, offsets of the file do not fit in 32 bits.
+	{Name: "file-5GiB-balanced-raw", Source: SyntheticFile{Size: 5 << 30, Seed: 8, Chunker: "size-1048576", Layout: LayoutBalanced, RawLeaves: true}},
+	{Name: "dir-hamt-10000-entries", Source: SyntheticDirectory{Entries: 10000, EntrySize: 64, Seed: 9}},
+}
+
+func GetSyntheticFixture(name string) (SyntheticFixture, error) {
+	for _, f := range SyntheticFixtures {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return SyntheticFixture{}, fmt.Errorf("unknown synthetic fixture %q", name)
+}
+
+// WriteCar builds the fixture into a CARv2 file. The file is written next to
+// the path and renamed once complete, a partial file is never left at path.
+func (f SyntheticFixture) WriteCar(path string) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	err = errors.Join(tmp.Close(), os.Remove(tmpPath))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// The root is only known once the DAG is built, write a placeholder of
+	// the same length in the header and replace it at the end.
+	placeholder, err := cidBuilder.Sum(nil)
+	if err != nil {
+		return err
+	}
+	bs, err := blockstore.OpenReadWrite(tmpPath, []cid.Cid{placeholder})
+	if err != nil {
+		return err
+	}
+	dserv := merkledag.NewDAGService(blockservice.New(bs, nil))
+
+	root, err := f.Source.Build(context.Background(), dserv)
+	if err != nil {
+		bs.Discard()
+		return err
+	}
+	err = bs.Finalize()
+	if err != nil {
+		return err
+	}
+	err = carv2.ReplaceRootsInFile(tmpPath, []cid.Cid{root.Cid()})
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// syntheticVersion is bumped on every change of the DAGs generated from the
+// same parameters, development builds share the "dev" version of the tool.
+const syntheticVersion = 1
+
+// cacheKey identifies the CAR of the fixture generated by this version of the
+// tool: a change of its parameters or of the generator gives another file.
+func (f SyntheticFixture) cacheKey() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d %s %#v", syntheticVersion, tooling.Version, f.Source)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// SyntheticDir is where the synthetic fixtures loaded in tests are cached.
+func SyntheticDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gateway-conformance", "synthetic")
+}
+
+var syntheticMx sync.Mutex
+
+// MustOpenSyntheticCar opens a synthetic fixture, generating its CAR in the
+// SyntheticDir on first use. The cached CARs are named after the fixture, its
+// parameters and the version of the tool, and are never reused by another
+// version.
+func MustOpenSyntheticCar(name string) *UnixfsDag {
+	dag, err := openSyntheticCar(name)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return dag
+}
+
+func openSyntheticCar(name string) (*UnixfsDag, error) {
+	fixture, err := GetSyntheticFixture(name)
+	if err != nil {
+		return nil, err
+	}
+
+	syntheticMx.Lock()
+	defer syntheticMx.Unlock()
+
+	path := filepath.Join(SyntheticDir(), fmt.Sprintf("%s-%s.car", name, fixture.cacheKey()))
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = os.MkdirAll(SyntheticDir(), 0755)
+		if err != nil {
+			return nil, err
+		}
+		err = fixture.WriteCar(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newUnixfsDagFromCar(path)
+}
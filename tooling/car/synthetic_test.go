@@ -0,0 +1,175 @@
+package car
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/boxo/ipld/merkledag"
+	mdtest "github.com/ipfs/boxo/ipld/merkledag/test"
+	"github.com/ipfs/boxo/ipld/unixfs"
+	uio "github.com/ipfs/boxo/ipld/unixfs/io"
+	"github.com/ipfs/gateway-conformance/tooling"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildSynthetic(t *testing.T, source SyntheticSource) (*merkledag.ProtoNode, cid.Cid) {
+	t.Helper()
+
+	dserv := mdtest.Mock()
+	node, err := source.Build(context.Background(), dserv)
+	require.NoError(t, err)
+
+	pn, _ := node.(*merkledag.ProtoNode)
+	return pn, node.Cid()
+}
+
+func TestSyntheticFileIsDeterministic(t *testing.T) {
+	file := SyntheticFile{Size: 300_000, Seed: 42, Chunker: "size-4096", RawLeaves: true}
+
+	_, a := buildSynthetic(t, file)
+	_, b := buildSynthetic(t, file)
+	assert.Equal(t, a, b)
+
+	file.Seed = 43
+	_, c := buildSynthetic(t, file)
+	assert.NotEqual(t, a, c)
+
+	// A change of the generator changes the fixtures of the gateways
+	// provisioned with an earlier version, and requires a new
+	// syntheticVersion for the cached CARs.
+	assert.Equal(t, "bafybeih4mnlhshetrss57w2se4fzfhjcf6webylu7jo3jkzvhube5rkzn4", a.String())
+}
+
+func TestSyntheticFileLayouts(t *testing.T) {
+	balanced := SyntheticFile{Size: 1 << 20, Seed: 1, Chunker: "size-1024", Layout: LayoutBalanced}
+	trickle := balanced
+	trickle.Layout = LayoutTrickle
+
+	balancedNode, balancedCid := buildSynthetic(t, balanced)
+	_, trickleCid := buildSynthetic(t, trickle)
+	assert.NotEqual(t, balancedCid, trickleCid)
+
+	// 1024 leaves do not fit in a single node.
+	require.NotNil(t, balancedNode)
+	assert.Len(t, balancedNode.Links(), 6)
+	assert.Equal(t, uint64(cid.DagProtobuf), balancedCid.Prefix().Codec)
+
+	_, err := SyntheticFile{Size: 1, Layout: "unknown"}.Build(context.Background(), mdtest.Mock())
+	assert.ErrorContains(t, err, `unknown layout "unknown"`)
+}
+
+func TestSyntheticFileRawLeaves(t *testing.T) {
+	dserv := mdtest.Mock()
+	for _, rawLeaves := range []bool{true, false} {
+		file := SyntheticFile{Size: 10_000, Seed: 1, Chunker: "size-4096", RawLeaves: rawLeaves}
+		node, err := file.Build(context.Background(), dserv)
+		require.NoError(t, err)
+
+		codec := uint64(cid.DagProtobuf)
+		if rawLeaves {
+			codec = cid.Raw
+		}
+		require.Len(t, node.Links(), 3)
+		for _, l := range node.Links() {
+			assert.Equal(t, codec, l.Cid.Prefix().Codec)
+		}
+	}
+}
+
+func TestSyntheticFileContent(t *testing.T) {
+	for _, file := range []SyntheticFile{
+		{Size: 100_000, Seed: 1, Chunker: "size-1000", Layout: LayoutBalanced, RawLeaves: true},
+		{Size: 100_000, Seed: 1, Chunker: "size-1000", Layout: LayoutTrickle},
+		{Size: 100_000, Seed: 1, Chunker: "rabin-512-1024-2048", Layout: LayoutBalanced, RawLeaves: true},
+	} {
+		dserv := mdtest.Mock()
+		node, err := file.Build(context.Background(), dserv)
+		require.NoError(t, err)
+
+		r, err := uio.NewDagReader(context.Background(), node, dserv)
+		require.NoError(t, err)
+		actual, err := io.ReadAll(r)
+		require.NoError(t, err)
+
+		expected, err := io.ReadAll(file.Reader())
+		require.NoError(t, err)
+		assert.Len(t, expected, 100_000)
+		assert.Equal(t, expected, actual, "%s %s", file.Layout, file.Chunker)
+	}
+}
+
+func TestSyntheticDirectory(t *testing.T) {
+	dir := SyntheticDirectory{Entries: 1000, EntrySize: 16, Seed: 1}
+	assert.Equal(t, int64(16_000), dir.ContentSize())
+
+	dserv := mdtest.Mock()
+	node, err := dir.Build(context.Background(), dserv)
+	require.NoError(t, err)
+
+	fsn, err := unixfs.FSNodeFromBytes(node.(*merkledag.ProtoNode).Data())
+	require.NoError(t, err)
+	assert.Equal(t, unixfs.THAMTShard, fsn.Type())
+
+	d, err := uio.NewDirectoryFromNode(dserv, node)
+	require.NoError(t, err)
+	links, err := d.Links(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, links, 1000)
+
+	_, again := buildSynthetic(t, dir)
+	assert.Equal(t, node.Cid(), again)
+}
+
+func TestSyntheticFixtureWriteCar(t *testing.T) {
+	file := SyntheticFile{Size: 50_000, Seed: 1, Chunker: "size-1000", RawLeaves: true}
+	_, root := buildSynthetic(t, file)
+
+	path := filepath.Join(t.TempDir(), "synthetic.car")
+	fixture := SyntheticFixture{Name: "synthetic", Source: file}
+	require.NoError(t, fixture.WriteCar(path))
+
+	dag, err := newUnixfsDagFromCar(path)
+	require.NoError(t, err)
+	assert.Equal(t, root, dag.MustGetRoot().Cid())
+
+	expected, err := io.ReadAll(file.Reader())
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), dag.MustGetRoot().ReadFile())
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestSyntheticFixturesAreUnique(t *testing.T) {
+	names := map[string]bool{}
+	for _, f := range SyntheticFixtures {
+		assert.False(t, names[f.Name], f.Name)
+		names[f.Name] = true
+	}
+
+	_, err := GetSyntheticFixture("file-1MiB-balanced-raw")
+	assert.NoError(t, err)
+	_, err = GetSyntheticFixture("unknown")
+	assert.ErrorContains(t, err, `unknown synthetic fixture "unknown"`)
+}
+
+func TestSyntheticFixtureCacheKey(t *testing.T) {
+	fixture, err := GetSyntheticFixture("file-1MiB-balanced-raw")
+	require.NoError(t, err)
+	assert.Equal(t, fixture.cacheKey(), fixture.cacheKey())
+
+	other := fixture
+	other.Source = SyntheticFile{Size: 1 << 20, Seed: 2, Layout: LayoutBalanced, RawLeaves: true}
+	assert.NotEqual(t, fixture.cacheKey(), other.cacheKey())
+
+	version := tooling.Version
+	t.Cleanup(func() { tooling.Version = version })
+	key := fixture.cacheKey()
+	tooling.Version = "v0.0.0-other"
+	assert.NotEqual(t, key, fixture.cacheKey())
+}
@@ -0,0 +1,380 @@
+// Package traversal computes the blocks of the CAR response to a trustless
+// gateway request, as specified in
+// https://specs.ipfs.tech/http-gateways/trustless-gateway/#car-responses-application-vnd-ipld-car
+package traversal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/boxo/ipld/unixfs"
+	"github.com/ipfs/boxo/ipld/unixfs/hamt"
+	"github.com/ipfs/gateway-conformance/tooling/car"
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+)
+
+// Scope is the dag-scope of a request.
+type Scope string
+
+const (
+	// ScopeBlock returns the blocks needed to verify the path, and the
+	// block at the end of the path.
+	ScopeBlock Scope = "block"
+	// ScopeEntity returns the blocks needed to verify the path, and the
+	// blocks of the entity at the end of the path: the whole UnixFS file, or
+	// the range of entity-bytes, the blocks of a UnixFS directory listing,
+	// or the block of another codec.
+	ScopeEntity Scope = "entity"
+	// ScopeAll returns the blocks needed to verify the path, and the whole
+	// DAG at the end of the path.
+	ScopeAll Scope = "all"
+)
+
+// Request is a trustless gateway request of a CAR.
+type Request struct {
+	// Path is the content path, /ipfs/{cid}[/path].
+	Path string
+	// Scope is the dag-scope, ScopeAll when empty.
+	Scope Scope
+	// EntityBytes is the entity-bytes, from:to, e.g. 0:1023, -5:* or
+	// 512:-256. It only applies to UnixFS files with the ScopeEntity.
+	EntityBytes string
+	// Duplicates is true for dups=y: the blocks are sent every time they
+	// are traversed, instead of once.
+	Duplicates bool
+}
+
+// Blocks returns the CIDs of the blocks of the response to the request, in
+// the depth-first order of the traversal.
+func Blocks(ctx context.Context, dsvc format.DAGService, req Request) ([]cid.Cid, error) {
+	root, names, err := parsePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &walker{ctx: ctx, dsvc: dsvc, dups: req.Duplicates, seen: cid.NewSet()}
+
+	node, err := w.get(root)
+	if err != nil {
+		return nil, err
+	}
+	for len(names) > 0 {
+		w.emit(node.Cid())
+		node, names, err = w.resolve(node, names)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch req.Scope {
+	case ScopeBlock:
+		w.emit(node.Cid())
+	case ScopeEntity:
+		err = w.entity(node, req.EntityBytes)
+	case ScopeAll, "":
+		err = w.all(node)
+	default:
+		err = fmt.Errorf("unknown dag-scope %q", req.Scope)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.out, nil
+}
+
+func parsePath(p string) (cid.Cid, []string, error) {
+	rest, ok := strings.CutPrefix(p, "/ipfs/")
+	if !ok {
+		return cid.Undef, nil, fmt.Errorf("path %q is not an /ipfs/ content path", p)
+	}
+
+	var names []string
+	for i, segment := range strings.Split(rest, "/") {
+		name, err := url.PathUnescape(segment)
+		if err != nil {
+			return cid.Undef, nil, fmt.Errorf("path %q: %w", p, err)
+		}
+		if i == 0 || name != "" {
+			names = append(names, name)
+		}
+	}
+
+	root, err := cid.Decode(names[0])
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("path %q: %w", p, err)
+	}
+	return root, names[1:], nil
+}
+
+type walker struct {
+	ctx  context.Context
+	dsvc format.DAGService
+	dups bool
+	seen *cid.Set
+	out  []cid.Cid
+}
+
+// emit adds a block to the response, it returns false when the block was
+// already sent and is not sent again.
+func (w *walker) emit(c cid.Cid) bool {
+	if !w.seen.Visit(c) && !w.dups {
+		return false
+	}
+	w.out = append(w.out, c)
+	return true
+}
+
+func (w *walker) get(c cid.Cid) (format.Node, error) {
+	node, err := w.dsvc.Get(w.ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("block %s: %w", c, err)
+	}
+	return node, nil
+}
+
+func unixfsNode(node format.Node) (*merkledag.ProtoNode, *unixfs.FSNode, error) {
+	pb, ok := node.(*merkledag.ProtoNode)
+	if !ok {
+		return nil, nil, merkledag.ErrNotProtobuf
+	}
+	fsn, err := unixfs.FSNodeFromBytes(pb.Data())
+	if err != nil {
+		return nil, nil, fmt.Errorf("block %s: %w", node.Cid(), err)
+	}
+	return pb, fsn, nil
+}
+
+// resolve returns the node of the first path segments, the blocks needed to
+// verify them, other than the node itself, are sent.
+func (w *walker) resolve(node format.Node, names []string) (format.Node, []string, error) {
+	if node.Cid().Type() != cid.DagProtobuf {
+		// The path may go through the fields of the block before reaching a
+		// link, e.g. with DAG-CBOR.
+		lnk, rest, err := node.ResolveLink(names)
+		if err != nil {
+			return nil, nil, fmt.Errorf("block %s has no link at %s: %w", node.Cid(), strings.Join(names, "/"), err)
+		}
+		child, err := w.get(lnk.Cid)
+		return child, rest, err
+	}
+
+	pb, fsn, err := unixfsNode(node)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lnk *format.Link
+	switch fsn.Type() {
+	case unixfs.TDirectory:
+		lnk, err = pb.GetNodeLink(names[0])
+	case unixfs.THAMTShard:
+		lnk, err = w.findInHAMT(node, names[0])
+	default:
+		err = fmt.Errorf("block %s is not a UnixFS directory", node.Cid())
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving %s: %w", names[0], err)
+	}
+
+	child, err := w.get(lnk.Cid)
+	return child, names[1:], err
+}
+
+// findInHAMT finds an entry of a sharded directory, and sends the shards on
+// the way.
+func (w *walker) findInHAMT(node format.Node, name string) (*format.Link, error) {
+	tracker := &trackingDAGService{DAGService: w.dsvc}
+	h, err := hamt.NewHamtFromDag(tracker, node)
+	if err != nil {
+		return nil, err
+	}
+	lnk, err := h.Find(w.ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range tracker.requested {
+		w.emit(c)
+	}
+	return lnk, nil
+}
+
+func (w *walker) entity(node format.Node, entityBytes string) error {
+	if node.Cid().Type() != cid.DagProtobuf {
+		// The entity of a raw block, or of other codecs, is the block
+		// without its links.
+		w.emit(node.Cid())
+		return nil
+	}
+
+	_, fsn, err := unixfsNode(node)
+	if err != nil {
+		return err
+	}
+
+	switch fsn.Type() {
+	case unixfs.TFile, unixfs.TRaw:
+		from, to, err := parseEntityBytes(entityBytes, int64(fsn.FileSize()))
+		if err != nil {
+			return err
+		}
+		return w.file(node, 0, from, to)
+	case unixfs.THAMTShard:
+		return w.hamt(node)
+	default:
+		w.emit(node.Cid())
+		return nil
+	}
+}
+
+// file sends the blocks of a UnixFS file, starting at offset, needed for the
+// bytes from:to.
+func (w *walker) file(node format.Node, offset, from, to int64) error {
+	if !w.emit(node.Cid()) || node.Cid().Type() != cid.DagProtobuf {
+		return nil
+	}
+
+	pb, fsn, err := unixfsNode(node)
+	if err != nil {
+		return err
+	}
+
+	start := offset + int64(len(fsn.Data()))
+	for i, lnk := range pb.Links() {
+		if i >= fsn.NumChildren() {
+			break
+		}
+		size := int64(fsn.BlockSize(i))
+		if size > 0 && start <= to && start+size-1 >= from {
+			child, err := w.get(lnk.Cid)
+			if err != nil {
+				return err
+			}
+			if err := w.file(child, start, from, to); err != nil {
+				return err
+			}
+		}
+		start += size
+	}
+	return nil
+}
+
+// hamt sends the shards of a sharded directory, not its entries.
+func (w *walker) hamt(node format.Node) error {
+	if !w.emit(node.Cid()) {
+		return nil
+	}
+
+	pb, fsn, err := unixfsNode(node)
+	if err != nil {
+		return err
+	}
+
+	// The links to the shards are named with the hex index of the shard
+	// only, the links to the entries are followed by the entry name.
+	padding := len(fmt.Sprintf("%X", fsn.Fanout()-1))
+	for _, lnk := range pb.Links() {
+		if len(lnk.Name) != padding {
+			continue
+		}
+		child, err := w.get(lnk.Cid)
+		if err != nil {
+			return err
+		}
+		if err := w.hamt(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// all sends the whole DAG.
+func (w *walker) all(node format.Node) error {
+	if !w.emit(node.Cid()) {
+		return nil
+	}
+
+	for _, lnk := range node.Links() {
+		child, err := w.get(lnk.Cid)
+		if err != nil {
+			return err
+		}
+		if err := w.all(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEntityBytes returns the first and last bytes, included, of the
+// entity-bytes of a file of the size. The last byte is before the first one
+// when the range is empty.
+func parseEntityBytes(entityBytes string, size int64) (int64, int64, error) {
+	if entityBytes == "" {
+		return 0, size - 1, nil
+	}
+
+	fromStr, toStr, ok := strings.Cut(entityBytes, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("entity-bytes %q is not from:to", entityBytes)
+	}
+
+	from, err := strconv.ParseInt(fromStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("entity-bytes %q: %w", entityBytes, err)
+	}
+	if from < 0 {
+		from = max(size+from, 0)
+	}
+
+	to := size - 1
+	if toStr != "*" {
+		if to, err = strconv.ParseInt(toStr, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("entity-bytes %q: %w", entityBytes, err)
+		}
+		if to < 0 {
+			to += size
+		}
+		to = min(to, size-1)
+	}
+
+	return from, to, nil
+}
+
+// trackingDAGService records the blocks fetched, in order.
+type trackingDAGService struct {
+	format.DAGService
+	mu        sync.Mutex
+	requested []cid.Cid
+}
+
+func (d *trackingDAGService) Get(ctx context.Context, c cid.Cid) (format.Node, error) {
+	node, err := d.DAGService.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.requested = append(d.requested, c)
+	d.mu.Unlock()
+	return node, nil
+}
+
+// MustGetBlocks returns the CIDs of the blocks of the response to the request
+// for the fixture, e.g. to use with check.IsCar().HasBlocks(...).
+func MustGetBlocks(dag *car.UnixfsDag, req Request) []string {
+	cids, err := Blocks(context.Background(), dag.DAGService(), req)
+	if err != nil {
+		panic(err)
+	}
+
+	out := make([]string, 0, len(cids))
+	for _, c := range cids {
+		out = append(out, c.String())
+	}
+	return out
+}
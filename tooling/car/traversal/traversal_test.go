@@ -0,0 +1,154 @@
+package traversal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/car"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func concat(values ...interface{}) []string {
+	var out []string
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			out = append(out, v)
+		case []string:
+			out = append(out, v...)
+		}
+	}
+	return out
+}
+
+func TestBlocksPathing(t *testing.T) {
+	subdir := car.MustOpenUnixfsCar("trustless_gateway_car/subdir-with-two-single-block-files.car")
+	hamt := car.MustOpenUnixfsCar("trustless_gateway_car/single-layer-hamt-with-multi-block-files.car")
+	cbor := car.MustOpenUnixfsCar("trustless_gateway_car/dir-with-dag-cbor-with-links.car")
+
+	assert.Equal(t,
+		[]string{subdir.MustGetCid(), subdir.MustGetCid("subdir")},
+		MustGetBlocks(subdir, Request{Path: "/ipfs/" + subdir.MustGetCid() + "/subdir", Scope: ScopeBlock}),
+	)
+	assert.Equal(t,
+		[]string{subdir.MustGetCid(), subdir.MustGetCid("subdir"), subdir.MustGetCid("subdir", "ascii.txt")},
+		MustGetBlocks(subdir, Request{Path: "/ipfs/" + subdir.MustGetCid() + "/subdir/ascii.txt"}),
+	)
+	assert.Equal(t,
+		concat(hamt.MustGetCid(), hamt.MustGetCIDsInHAMTTraversal(nil, "1.txt"), hamt.MustGetCid("1.txt")),
+		MustGetBlocks(hamt, Request{Path: "/ipfs/" + hamt.MustGetCid() + "/1.txt", Scope: ScopeBlock}),
+	)
+	assert.Equal(t,
+		[]string{cbor.MustGetCid(), cbor.MustGetCid("document")},
+		MustGetBlocks(cbor, Request{Path: "/ipfs/" + cbor.MustGetCid() + "/document", Scope: ScopeEntity}),
+	)
+}
+
+func TestBlocksScopes(t *testing.T) {
+	mixed := car.MustOpenUnixfsCar("trustless_gateway_car/subdir-with-mixed-block-files.car")
+	hamt := car.MustOpenUnixfsCar("trustless_gateway_car/single-layer-hamt-with-multi-block-files.car")
+
+	root := "/ipfs/" + mixed.MustGetCid()
+
+	assert.Equal(t,
+		[]string{mixed.MustGetCid()},
+		MustGetBlocks(mixed, Request{Path: root, Scope: ScopeEntity}),
+	)
+	assert.Equal(t,
+		concat(hamt.MustGetCid(), hamt.MustGetCidsInHAMT()),
+		MustGetBlocks(hamt, Request{Path: "/ipfs/" + hamt.MustGetCid(), Scope: ScopeEntity}),
+	)
+	assert.Equal(t,
+		concat(mixed.MustGetCid(), mixed.MustGetCid("subdir"), mixed.MustGetCid("subdir", "multiblock.txt"), mixed.MustGetDescendantsCids("subdir", "multiblock.txt")),
+		MustGetBlocks(mixed, Request{Path: root + "/subdir/multiblock.txt", Scope: ScopeEntity}),
+	)
+	assert.Equal(t,
+		concat(mixed.MustGetCid(), mixed.MustGetCid("subdir"), mixed.MustGetDescendantsCids("subdir")),
+		MustGetBlocks(mixed, Request{Path: root + "/subdir", Scope: ScopeAll}),
+	)
+}
+
+func TestBlocksEntityBytes(t *testing.T) {
+	mixed := car.MustOpenUnixfsCar("trustless_gateway_car/subdir-with-mixed-block-files.car")
+
+	// multiblock.txt is 1026 bytes, in 4 chunks of 256 bytes and one of 2.
+	file := mixed.MustGetCid("subdir", "multiblock.txt")
+	chunks := mixed.MustGetDescendantsCids("subdir", "multiblock.txt")
+	require.Len(t, chunks, 5)
+
+	tests := []struct {
+		entityBytes string
+		expected    []string
+	}{
+		{"0:*", chunks},
+		{"512:*", chunks[2:]},
+		{"512:1023", chunks[2:4]},
+		{"512:-256", chunks[2:4]},
+		{"-5:*", chunks[3:]},
+		{"-9999:*", chunks},
+		{"-9999:-3", chunks[:4]},
+		{"0:0", chunks[:1]},
+		{"0:99999", chunks},
+		{"1000:10", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.entityBytes, func(t *testing.T) {
+			blocks := MustGetBlocks(mixed, Request{Path: "/ipfs/" + file, Scope: ScopeEntity, EntityBytes: test.entityBytes})
+			assert.Equal(t, concat(file, test.expected), blocks)
+		})
+	}
+}
+
+func TestBlocksMissingBlock(t *testing.T) {
+	f := car.MustOpenUnixfsCar("trustless_gateway_car/file-3k-and-3-blocks-missing-block.car")
+	root := "/ipfs/" + f.MustGetCid()
+
+	assert.Equal(t,
+		[]string{f.MustGetCid(), "QmPKt7ptM2ZYSGPUc8PmPT2VBkLDK3iqpG9TBJY7PCE9rF"},
+		MustGetBlocks(f, Request{Path: root, Scope: ScopeEntity, EntityBytes: "0:1000"}),
+	)
+	assert.Equal(t,
+		[]string{f.MustGetCid(), "QmWXY482zQdwecnfBsj78poUUuPXvyw2JAFAEMw4tzTavV"},
+		MustGetBlocks(f, Request{Path: root, Scope: ScopeEntity, EntityBytes: "2200:*"}),
+	)
+
+	_, err := Blocks(context.Background(), f.DAGService(), Request{Path: root, Scope: ScopeAll})
+	assert.Error(t, err)
+}
+
+func TestBlocksDuplicates(t *testing.T) {
+	f := car.MustOpenUnixfsCar("trustless_gateway_car/dir-with-duplicate-files.car")
+	root := "/ipfs/" + f.MustGetCid()
+
+	once := MustGetBlocks(f, Request{Path: root})
+	dups := MustGetBlocks(f, Request{Path: root, Duplicates: true})
+
+	assert.Equal(t, f.MustGetCid("ascii.txt"), f.MustGetCid("ascii-copy.txt"))
+	assert.Less(t, len(once), len(dups))
+	assert.Equal(t, 1, count(once, f.MustGetCid("ascii.txt")))
+	assert.Equal(t, 2, count(dups, f.MustGetCid("ascii.txt")))
+}
+
+func count(values []string, v string) int {
+	n := 0
+	for _, value := range values {
+		if value == v {
+			n++
+		}
+	}
+	return n
+}
+
+func TestParseEntityBytes(t *testing.T) {
+	_, _, err := parseEntityBytes("10", 100)
+	assert.Error(t, err)
+	_, _, err = parseEntityBytes("a:10", 100)
+	assert.Error(t, err)
+
+	from, to, err := parseEntityBytes("-10:-1", 100)
+	require.NoError(t, err)
+	assert.Equal(t, int64(90), from)
+	assert.Equal(t, int64(99), to)
+}
@@ -297,6 +297,21 @@ func (d *UnixfsDag) MustGetRawData(names ...string) []byte {
 	return d.mustGetNode(names...).RawData()
 }
 
+// GetBlockData returns the data of a block of the fixture, and false when the
+// fixture does not have it.
+func (d *UnixfsDag) GetBlockData(c cid.Cid) ([]byte, bool) {
+	node, err := d.dsvc.Get(context.Background(), c)
+	if err != nil {
+		return nil, false
+	}
+	return node.RawData(), true
+}
+
+// DAGService returns the blocks of the fixture.
+func (d *UnixfsDag) DAGService() format.DAGService {
+	return d.dsvc
+}
+
 func (d *UnixfsDag) MustGetFormattedDagNode(codecStr string, names ...string) []byte {
 	node := d.mustGetNode(names...).(ipld.Node)
 	return FormatDagNode(node, codecStr)
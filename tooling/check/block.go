@@ -0,0 +1,49 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+)
+
+// CheckIsBlock checks a raw block response: its data must hash to the
+// multihash of the requested CID.
+type CheckIsBlock struct {
+	cid  cid.Cid
+	data []byte
+}
+
+var _ Check[[]byte] = (*CheckIsBlock)(nil)
+
+func IsBlock(cidStr string) *CheckIsBlock {
+	return &CheckIsBlock{
+		cid: decoded(cidStr),
+	}
+}
+
+// WithData also compares the block with the data of the fixture.
+func (c CheckIsBlock) WithData(data []byte) *CheckIsBlock {
+	c.data = data
+	return &c
+}
+
+func (c *CheckIsBlock) Check(v []byte) CheckOutput {
+	if err := verifyBlock(c.cid, v); err != nil {
+		return CheckOutput{
+			Success: false,
+			Reason:  err.Error(),
+		}
+	}
+
+	if c.data != nil && !bytes.Equal(v, c.data) {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("block %s does not match the block of the fixture: expected %d bytes, got %d bytes", c.cid, len(c.data), len(v)),
+		}
+	}
+
+	return CheckOutput{
+		Success: true,
+	}
+}
@@ -1,9 +1,21 @@
 package check
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 
+	"github.com/ipfs/boxo/blockservice"
+	"github.com/ipfs/boxo/blockstore"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/gateway-conformance/tooling/car"
+	"github.com/ipfs/gateway-conformance/tooling/car/traversal"
+	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	format "github.com/ipfs/go-ipld-format"
 )
 
 type CheckIsCarFile struct {
@@ -13,9 +25,24 @@ type CheckIsCarFile struct {
 	mightHaveNoRoots bool
 	isExact          bool
 	isOrdered        bool
+	blocks           BlockGetter
+	request          *traversal.Request
+	requestDAG       format.DAGService
+	noDuplicates     bool
+	version          uint64
+	requireIndex     bool
+}
+
+// BlockGetter gives the data of the blocks of a fixture, e.g. a
+// *car.UnixfsDag, see CheckIsCarFile.MatchesBlocksOf.
+type BlockGetter interface {
+	// GetBlockData returns the data of a block, and false when the block is
+	// not in the fixture.
+	GetBlockData(c cid.Cid) ([]byte, bool)
 }
 
 var _ Check[[]byte] = (*CheckIsCarFile)(nil)
+var _ ReaderCheck = (*CheckIsCarFile)(nil)
 
 func IsCar() *CheckIsCarFile {
 	return &CheckIsCarFile{
@@ -78,14 +105,157 @@ func (c CheckIsCarFile) InThatOrder() *CheckIsCarFile {
 	return &c
 }
 
+// MatchesBlocksOf compares the data of the blocks of the CAR with the data of
+// the same blocks in the fixture. The blocks missing from the fixture are
+// only verified against their multihash.
+func (c CheckIsCarFile) MatchesBlocksOf(blocks BlockGetter) *CheckIsCarFile {
+	c.blocks = blocks
+	return &c
+}
+
+// SatisfiesRequest expects, after the blocks of HasBlocks, the blocks of the
+// response to a trustless gateway request: the blocks the traversal of the
+// path with the dag-scope and entity-bytes visits, computed from the blocks
+// of the CAR itself. An empty scope is the default dag-scope=all, an empty
+// entityBytes the whole entity. The blocks are kept in memory to be
+// traversed, the check can not be used with Streaming.
+func (c CheckIsCarFile) SatisfiesRequest(path, scope, entityBytes string) *CheckIsCarFile {
+	c.request = &traversal.Request{
+		Path:        path,
+		Scope:       traversal.Scope(scope),
+		EntityBytes: entityBytes,
+	}
+	return &c
+}
+
+// SatisfiesRequestOf is SatisfiesRequest with the blocks computed from the
+// fixture instead of the CAR, as traversal.MustGetBlocks does: the blocks the
+// response leaves out are reported as missing, and the CAR is not kept in
+// memory, the check can be used with Streaming.
+func (c CheckIsCarFile) SatisfiesRequestOf(fixture *car.UnixfsDag, path, scope, entityBytes string) *CheckIsCarFile {
+	c.request = &traversal.Request{
+		Path:        path,
+		Scope:       traversal.Scope(scope),
+		EntityBytes: entityBytes,
+	}
+	c.requestDAG = fixture.DAGService()
+	return &c
+}
+
+// WithDuplicates expects the traversal of SatisfiesRequest to send the blocks
+// every time they are traversed, as with dups=y. Without InThatOrder, the
+// blocks must still be sent as many times as they are traversed.
+func (c CheckIsCarFile) WithDuplicates() *CheckIsCarFile {
+	if c.request == nil {
+		panic("WithDuplicates must be used after SatisfiesRequest")
+	}
+	request := *c.request
+	request.Duplicates = true
+	c.request = &request
+	return &c
+}
+
+// HasNoDuplicates expects every block to be sent once, as with dups=n.
+func (c CheckIsCarFile) HasNoDuplicates() *CheckIsCarFile {
+	c.noDuplicates = true
+	return &c
+}
+
+// WithVersion expects a CAR of the version, 1 or 2. Both are accepted by
+// default.
+func (c CheckIsCarFile) WithVersion(version uint64) *CheckIsCarFile {
+	c.version = version
+	return &c
+}
+
+// RequiresIndex expects a CARv2 with an index that has the offsets of all the
+// blocks of its data payload.
+func (c CheckIsCarFile) RequiresIndex() *CheckIsCarFile {
+	c.version = 2
+	c.requireIndex = true
+	return &c
+}
+
 func (c *CheckIsCarFile) Check(carContent []byte) CheckOutput {
-	gotCIDs, err := listAllCids(carContent)
+	return c.CheckReader(bytes.NewReader(carContent))
+}
+
+// CheckReader checks a CARv1 or CARv2 stream block by block, without
+// buffering it, except with SatisfiesRequest which keeps the blocks in memory,
+// unlike SatisfiesRequestOf.
+// The data of every block must hash to the multihash of its CID.
+func (c *CheckIsCarFile) CheckReader(r io.Reader) CheckOutput {
+	var invalid error
+	bs := blockstore.NewBlockstore(dssync.MutexWrap(datastore.NewMapDatastore()))
+	f, err := readCar(r, c.requireIndex, func(blk cid.Cid, data []byte) error {
+		if invalid = c.verifyBlock(blk, data); invalid != nil {
+			return invalid
+		}
+		if c.request == nil || c.requestDAG != nil {
+			return nil
+		}
+		b, err := blocks.NewBlockWithCid(data, blk)
+		if err != nil {
+			return err
+		}
+		return bs.Put(context.Background(), b)
+	})
+	if invalid != nil {
+		return CheckOutput{
+			Success: false,
+			Reason:  invalid.Error(),
+		}
+	}
 	if err != nil {
 		return CheckOutput{
 			Success: false,
 			Reason:  fmt.Sprintf("failed to list all cids: %v", err),
 		}
 	}
+	gotRoots, gotCIDs := f.roots, f.cids
+
+	if c.version != 0 && f.version != c.version {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("expected a CARv%d, got a CARv%d", c.version, f.version),
+		}
+	}
+	if c.requireIndex {
+		if err := f.verifyIndex(); err != nil {
+			return CheckOutput{
+				Success: false,
+				Reason:  err.Error(),
+			}
+		}
+	}
+
+	if c.noDuplicates {
+		seen := cid.NewSet()
+		for _, blk := range gotCIDs {
+			if !seen.Visit(blk) {
+				return CheckOutput{
+					Success: false,
+					Reason:  fmt.Sprintf("block %s is sent more than once", blk),
+				}
+			}
+		}
+	}
+
+	expectedCIDs := c.blockCIDs
+	if c.request != nil {
+		dsvc := c.requestDAG
+		if dsvc == nil {
+			dsvc = merkledag.NewDAGService(blockservice.New(bs, nil))
+		}
+		requested, err := traversal.Blocks(context.Background(), dsvc, *c.request)
+		if err != nil {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("the CAR does not satisfy the request of %s: %v", c.request.Path, err),
+			}
+		}
+		expectedCIDs = append(expectedCIDs[:len(expectedCIDs):len(expectedCIDs)], requested...)
+	}
 
 	cmp := CidSetContains
 
@@ -101,21 +271,27 @@ func (c *CheckIsCarFile) Check(carContent []byte) CheckOutput {
 		}
 	}
 
-	output := cmp(gotCIDs, c.blockCIDs)
+	// The blocks sent many times must be sent as many times as they are
+	// traversed, in any order.
+	blocksCmp := cmp
+	if c.request != nil && c.request.Duplicates && !c.isOrdered {
+		if c.isExact {
+			blocksCmp = CidMultisetEquals
+		} else {
+			blocksCmp = CidMultisetContains
+		}
+	}
+
+	output := blocksCmp(gotCIDs, expectedCIDs)
 
 	if !output.Success {
+		if c.request != nil {
+			output.Reason = fmt.Sprintf("the CAR does not satisfy the request of %s: %s", c.request.Path, output.Reason)
+		}
 		return output
 	}
 
 	if (len(c.rootCIDs) > 0 || c.isExact) && !c.ignoreRoots {
-		gotRoots, err := listAllRoots(carContent)
-		if err != nil {
-			return CheckOutput{
-				Success: false,
-				Reason:  fmt.Sprintf("failed to list all roots: %v", err),
-			}
-		}
-
 		if !(c.mightHaveNoRoots && len(gotRoots) == 0) {
 			output = cmp(gotRoots, c.rootCIDs)
 			if !output.Success {
@@ -128,3 +304,16 @@ func (c *CheckIsCarFile) Check(carContent []byte) CheckOutput {
 		Success: true,
 	}
 }
+
+func (c *CheckIsCarFile) verifyBlock(blk cid.Cid, data []byte) error {
+	if err := verifyBlock(blk, data); err != nil {
+		return err
+	}
+	if c.blocks == nil {
+		return nil
+	}
+	if expected, ok := c.blocks.GetBlockData(blk); ok && !bytes.Equal(data, expected) {
+		return fmt.Errorf("block %s does not match the block of the fixture: expected %d bytes, got %d bytes", blk, len(expected), len(data))
+	}
+	return nil
+}
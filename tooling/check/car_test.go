@@ -1,11 +1,23 @@
 package check
 
 import (
+	"bytes"
 	"io"
 	"os"
 	"testing"
 
+	ft "github.com/ipfs/boxo/ipld/unixfs"
+	gwcar "github.com/ipfs/gateway-conformance/tooling/car"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/ipld/go-car"
+	"github.com/ipld/go-car/util"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/ipld/go-car/v2/index"
+	mh "github.com/multiformats/go-multihash"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func loadFile(t *testing.T, carFilePath string) []byte {
@@ -110,3 +122,241 @@ func TestHasFile(t *testing.T) {
 
 	assert.False(t, c5.Check(block).Success)
 }
+
+// writeCar writes a CARv1 with the blocks as given, without verifying them.
+func writeCar(t *testing.T, root cid.Cid, blocks ...blocks.Block) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: []cid.Cid{root}, Version: 1}, &buf))
+	for _, b := range blocks {
+		require.NoError(t, util.LdWrite(&buf, b.Cid().Bytes(), b.RawData()))
+	}
+	return buf.Bytes()
+}
+
+type blockMap map[cid.Cid][]byte
+
+func (m blockMap) GetBlockData(c cid.Cid) ([]byte, bool) {
+	data, ok := m[c]
+	return data, ok
+}
+
+func TestIsCarVerifiesBlocks(t *testing.T) {
+	hello := makeCID("hello")
+	world := makeCID("world")
+
+	block := func(c cid.Cid, data string) blocks.Block {
+		b, err := blocks.NewBlockWithCid([]byte(data), c)
+		require.NoError(t, err)
+		return b
+	}
+
+	valid := writeCar(t, hello, block(hello, "hello"), block(world, "world"))
+	assert.True(t, IsCar().HasBlocks(hello.String(), world.String()).Check(valid).Success)
+
+	// The block is sent under the CID of another block.
+	output := IsCar().HasBlock(hello.String()).Check(writeCar(t, hello, block(hello, "hello"), block(world, "corrupted")))
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "block "+world.String()+" does not match its multihash")
+
+	// The data of the fixture is compared when it has the block.
+	fixture := blockMap{hello: []byte("hello")}
+	assert.True(t, IsCar().MatchesBlocksOf(fixture).Check(valid).Success)
+	fixture[world] = []byte("other")
+	output = IsCar().MatchesBlocksOf(fixture).Check(valid)
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "block "+world.String()+" does not match the block of the fixture")
+}
+
+func TestIsBlock(t *testing.T) {
+	hello := makeCID("hello")
+
+	assert.True(t, IsBlock(hello.String()).Check([]byte("hello")).Success)
+	assert.True(t, IsBlock(hello.String()).WithData([]byte("hello")).Check([]byte("hello")).Success)
+
+	output := IsBlock(hello.String()).Check([]byte("hellp"))
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "block "+hello.String()+" does not match its multihash")
+
+	// CIDv0, and an identity multihash.
+	v0 := cid.NewCidV0(hello.Hash())
+	assert.True(t, IsBlock(v0.String()).Check([]byte("hello")).Success)
+	identity, err := cid.V1Builder{Codec: cid.Raw, MhType: mh.IDENTITY}.Sum([]byte("inline"))
+	require.NoError(t, err)
+	assert.True(t, IsBlock(identity.String()).Check([]byte("inline")).Success)
+	assert.False(t, IsBlock(identity.String()).Check([]byte("online")).Success)
+}
+
+func TestIsCarSatisfiesRequest(t *testing.T) {
+	file := loadFile(t, "./_fixtures/dag.car")
+
+	root := "bafybeidlbwbu73tbjr3atntjz4lq5ego5w2uyof35vvwcnheaftzi3rndu"
+	leaf := "bafkreihdhgb5vyuqu7jssreyo3h567obewtqq37fi5hr2w4um5icacry7m"
+
+	assert.True(t, IsCar().IgnoreRoots().SatisfiesRequest("/ipfs/"+root, "all", "").Exactly().Check(file).Success)
+	assert.True(t, IsCar().SatisfiesRequest("/ipfs/"+root+"/subdir/leaf.txt", "block", "").Check(file).Success)
+	assert.True(t, IsCar().HasBlock(leaf).SatisfiesRequest("/ipfs/"+root+"/subdir", "entity", "").Check(file).Success)
+
+	// The CAR of the fixture is not in the order of the traversal, and has
+	// blocks the request does not need.
+	assert.False(t, IsCar().IgnoreRoots().SatisfiesRequest("/ipfs/"+root, "all", "").Exactly().InThatOrder().Check(file).Success)
+	assert.False(t, IsCar().IgnoreRoots().SatisfiesRequest("/ipfs/"+root+"/subdir", "block", "").Exactly().Check(file).Success)
+
+	// The CAR misses a block of the traversal.
+	var sent []blocks.Block
+	_, err := readCar(bytes.NewReader(file), false, func(c cid.Cid, data []byte) error {
+		if c.String() != leaf {
+			b, err := blocks.NewBlockWithCid(data, c)
+			sent = append(sent, b)
+			return err
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	partial := writeCar(t, decoded(root), sent...)
+
+	assert.True(t, IsCar().SatisfiesRequest("/ipfs/"+root+"/subdir", "block", "").Check(partial).Success)
+	output := IsCar().SatisfiesRequest("/ipfs/"+root+"/subdir", "all", "").Check(partial)
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "the CAR does not satisfy the request of /ipfs/"+root+"/subdir")
+	assert.Contains(t, output.Reason, leaf)
+}
+
+func TestIsCarSatisfiesRequestOf(t *testing.T) {
+	file := loadFile(t, "./_fixtures/dag.car")
+	fixture := gwcar.MustOpenUnixfsCar("./_fixtures/dag.car")
+
+	root := "bafybeidlbwbu73tbjr3atntjz4lq5ego5w2uyof35vvwcnheaftzi3rndu"
+	leaf := "bafkreihdhgb5vyuqu7jssreyo3h567obewtqq37fi5hr2w4um5icacry7m"
+
+	assert.True(t, IsCar().IgnoreRoots().SatisfiesRequestOf(fixture, "/ipfs/"+root, "all", "").Exactly().Check(file).Success)
+	assert.True(t, IsCar().SatisfiesRequestOf(fixture, "/ipfs/"+root+"/subdir/leaf.txt", "block", "").Check(file).Success)
+	assert.True(t, Streaming(IsCar().SatisfiesRequestOf(fixture, "/ipfs/"+root, "all", "")).Check(bytes.NewReader(file)).Success)
+
+	// The blocks of a subtree left out are reported, where the traversal of
+	// the CAR itself only stops at the missing link.
+	var sent []blocks.Block
+	_, err := readCar(bytes.NewReader(file), false, func(c cid.Cid, data []byte) error {
+		if c.String() != leaf {
+			b, err := blocks.NewBlockWithCid(data, c)
+			sent = append(sent, b)
+			return err
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	partial := writeCar(t, decoded(root), sent...)
+
+	output := IsCar().SatisfiesRequestOf(fixture, "/ipfs/"+root+"/subdir", "all", "").Check(partial)
+	assert.False(t, output.Success)
+	assert.Equal(t, "the CAR does not satisfy the request of /ipfs/"+root+"/subdir: missing CID "+leaf, output.Reason)
+}
+
+func TestIsCarDuplicates(t *testing.T) {
+	hello := makeCID("hello")
+	world := makeCID("world")
+
+	block := func(c cid.Cid, data string) blocks.Block {
+		b, err := blocks.NewBlockWithCid([]byte(data), c)
+		require.NoError(t, err)
+		return b
+	}
+
+	once := writeCar(t, hello, block(hello, "hello"), block(world, "world"))
+	twice := writeCar(t, hello, block(hello, "hello"), block(world, "world"), block(hello, "hello"))
+
+	assert.True(t, IsCar().HasNoDuplicates().Check(once).Success)
+	output := IsCar().HasNoDuplicates().Check(twice)
+	assert.False(t, output.Success)
+	assert.Equal(t, "block "+hello.String()+" is sent more than once", output.Reason)
+
+	assert.Panics(t, func() { IsCar().WithDuplicates() })
+
+	// A directory with the same file twice, traversed twice with dups=y.
+	dir := ft.EmptyDirNode()
+	dir.SetCidBuilder(cid.V1Builder{Codec: cid.DagProtobuf, MhType: mh.SHA2_256})
+	require.NoError(t, dir.AddRawLink("a.txt", &format.Link{Cid: hello, Size: 5}))
+	require.NoError(t, dir.AddRawLink("b.txt", &format.Link{Cid: hello, Size: 5}))
+	path := "/ipfs/" + dir.Cid().String()
+
+	withDups := IsCar().IgnoreRoots().SatisfiesRequest(path, "all", "").WithDuplicates()
+	sentTwice := writeCar(t, dir.Cid(), block(hello, "hello"), dir, block(hello, "hello"))
+	sentOnce := writeCar(t, dir.Cid(), dir, block(hello, "hello"))
+
+	assert.True(t, withDups.Check(sentTwice).Success)
+	assert.True(t, withDups.Exactly().Check(sentTwice).Success)
+	output = withDups.Check(sentOnce)
+	assert.False(t, output.Success)
+	assert.Equal(t, "the CAR does not satisfy the request of "+path+": expected CID "+hello.String()+" 2 times, got it 1 times", output.Reason)
+	assert.False(t, withDups.Exactly().Check(writeCar(t, dir.Cid(), dir, block(hello, "hello"), block(hello, "hello"), block(hello, "hello"))).Success)
+}
+
+// writeCarV2 wraps a CARv1 in a CARv2, with the index when it is not nil.
+func writeCarV2(t *testing.T, payload []byte, idx index.Index) []byte {
+	var buf bytes.Buffer
+	header := carv2.NewHeader(uint64(len(payload)))
+	if idx == nil {
+		header.IndexOffset = 0
+	}
+	_, err := buf.Write(carv2.Pragma)
+	require.NoError(t, err)
+	_, err = header.WriteTo(&buf)
+	require.NoError(t, err)
+	buf.Write(payload)
+	if idx != nil {
+		_, err = index.WriteTo(idx, &buf)
+		require.NoError(t, err)
+	}
+	return buf.Bytes()
+}
+
+func TestIsCarV2(t *testing.T) {
+	file := loadFile(t, "./_fixtures/dag.car")
+	root := "bafybeidlbwbu73tbjr3atntjz4lq5ego5w2uyof35vvwcnheaftzi3rndu"
+
+	var wrapped bytes.Buffer
+	require.NoError(t, carv2.WrapV1(bytes.NewReader(file), &wrapped))
+	v2 := wrapped.Bytes()
+
+	assert.True(t, IsCar().HasRoot(root).HasBlock(root).Check(v2).Success)
+	assert.True(t, IsCar().HasRoot(root).WithVersion(2).RequiresIndex().Check(v2).Success)
+	assert.True(t, Streaming(IsCar().RequiresIndex()).Check(bytes.NewReader(v2)).Success)
+	assert.True(t, IsCar().WithVersion(1).Check(file).Success)
+
+	output := IsCar().WithVersion(1).Check(v2)
+	assert.False(t, output.Success)
+	assert.Equal(t, "expected a CARv1, got a CARv2", output.Reason)
+
+	output = IsCar().RequiresIndex().Check(file)
+	assert.False(t, output.Success)
+	assert.Equal(t, "expected a CARv2, got a CARv1", output.Reason)
+
+	// Without an index.
+	noIndex := writeCarV2(t, file, nil)
+	assert.True(t, IsCar().HasRoot(root).WithVersion(2).Check(noIndex).Success)
+	output = IsCar().RequiresIndex().Check(noIndex)
+	assert.False(t, output.Success)
+	assert.Equal(t, "the CARv2 has no index", output.Reason)
+
+	// With the index of another data payload.
+	hello := makeCID("hello")
+	world := makeCID("world")
+	block := func(c cid.Cid, data string) blocks.Block {
+		b, err := blocks.NewBlockWithCid([]byte(data), c)
+		require.NoError(t, err)
+		return b
+	}
+	payload := writeCar(t, hello, block(hello, "hello"), block(world, "world"))
+	other, err := carv2.GenerateIndex(bytes.NewReader(writeCar(t, hello, block(world, "world"), block(hello, "hello"))))
+	require.NoError(t, err)
+	output = IsCar().RequiresIndex().Check(writeCarV2(t, payload, other))
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "the index does not have the offset")
+
+	idx, err := carv2.GenerateIndex(bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.True(t, IsCar().RequiresIndex().HasBlocks(hello.String(), world.String()).Check(writeCarV2(t, payload, idx)).Success)
+
+	// A truncated data payload.
+	output = IsCar().Check(v2[:len(v2)/2])
+	assert.False(t, output.Success)
+}
@@ -21,6 +21,9 @@ type CheckOutput struct {
 	Reason  string
 	Err     error
 	Hint    string
+	// Diff is set by the failed comparisons of long values, the report
+	// renders it below the reason.
+	Diff *Diff
 }
 
 type Check[T any] interface {
@@ -137,6 +140,18 @@ func (c CheckIsEqual[T]) Check(v T) CheckOutput {
 		}
 	}
 
+	// Long strings, e.g. an HTML directory listing, are rendered as a diff.
+	if expected, ok := any(c.Value).(string); ok {
+		actual := any(v).(string)
+		if !isShort([]byte(expected), []byte(actual)) {
+			return CheckOutput{
+				Success: false,
+				Reason:  "differs from the expected value, see the diff",
+				Diff:    &Diff{Format: DiffText, Expected: []byte(expected), Actual: []byte(actual)},
+			}
+		}
+	}
+
 	return CheckOutput{
 		Success: false,
 		Reason:  fmt.Sprintf("expected '%v', got '%v'", c.Value, v),
@@ -165,6 +180,18 @@ func (c CheckIsEqualBytes) Check(v []byte) CheckOutput {
 		}
 	}
 
+	if !isShort(c.Value, v) {
+		format := DiffBinary
+		if utf8.Valid(v) && utf8.Valid(c.Value) {
+			format = DiffText
+		}
+		return CheckOutput{
+			Success: false,
+			Reason:  "differs from the expected value, see the diff",
+			Diff:    &Diff{Format: format, Expected: c.Value, Actual: v},
+		}
+	}
+
 	var reason string
 	if utf8.Valid(v) && utf8.Valid(c.Value) {
 		// Print human-readable plain text, when possible
@@ -395,6 +422,14 @@ func (c *CheckIsJSONEqual) Check(v []byte) CheckOutput {
 		}
 	}
 
+	if !isShort(b, v) {
+		return CheckOutput{
+			Success: false,
+			Reason:  "differs from the expected JSON, see the diff",
+			Diff:    &Diff{Format: DiffJSON, Expected: b, Actual: v},
+		}
+	}
+
 	return CheckOutput{
 		Success: false,
 		Reason:  fmt.Sprintf("expected '%s', got '%s'", string(b), string(v)),
@@ -1,13 +1,18 @@
 package check
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipld/go-car"
-	"github.com/ipld/go-car/v2/blockstore"
+	"github.com/ipld/go-car/util"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/ipld/go-car/v2/index"
+	"github.com/multiformats/go-multihash"
 )
 
 func CidSetContains(a, b []cid.Cid) CheckOutput {
@@ -41,6 +46,43 @@ func CidSetEquals(a, b []cid.Cid) CheckOutput {
 	return CidSetContains(b, a)
 }
 
+// CidMultisetContains checks that every CID of b is in a at least as many
+// times as in b, in any order.
+func CidMultisetContains(a, b []cid.Cid) CheckOutput {
+	count := make(map[cid.Cid]int)
+	for _, c := range a {
+		count[c]++
+	}
+	expected := make(map[cid.Cid]int)
+	for _, c := range b {
+		expected[c]++
+	}
+
+	for _, c := range b {
+		if count[c] < expected[c] {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("expected CID %s %d times, got it %d times", c, expected[c], count[c]),
+			}
+		}
+	}
+
+	return CheckOutput{
+		Success: true,
+	}
+}
+
+func CidMultisetEquals(a, b []cid.Cid) CheckOutput {
+	if len(a) != len(b) {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("length mismatch: %d != %d", len(a), len(b)),
+		}
+	}
+
+	return CidMultisetContains(a, b)
+}
+
 func CidArrayEquals(a, b []cid.Cid) CheckOutput {
 	if len(a) != len(b) {
 		return CheckOutput{
@@ -88,36 +130,162 @@ func CidOrderedSubsetContains(a, b []cid.Cid) CheckOutput {
 	}
 }
 
-func listAllCids(carContent []byte) ([]cid.Cid, error) {
-	reader := bytes.NewReader(carContent)
-	cr, err := car.NewCarReader(reader)
+// carFile is what readCar reads of a CAR.
+type carFile struct {
+	version uint64
+	roots   []cid.Cid
+	cids    []cid.Cid
+	// offsets are the offsets of the sections of the blocks in the CARv1
+	// data payload, the ones of a CARv2 index.
+	offsets []uint64
+	// index is the index of a CARv2, nil when the CAR has none or when it is
+	// not read.
+	index index.Index
+}
+
+// countingReader counts the bytes read, the position of a bufio.Reader on top
+// of it is n - Buffered().
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readCar reads the roots and the CIDs of the blocks of a CARv1 or of the data
+// payload of a CARv2, block by block, and calls visit with the data of every
+// block. The blocks are not verified, see verifyBlock. The index of a CARv2 is
+// only read with withIndex.
+func readCar(r io.Reader, withIndex bool, visit func(c cid.Cid, data []byte) error) (*carFile, error) {
+	cr := &countingReader{r: r}
+	br := bufio.NewReader(cr)
+	pos := func() int64 { return cr.n - int64(br.Buffered()) }
+
+	header, err := car.ReadHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	switch header.Version {
+	case 1:
+		f := &carFile{version: 1, roots: header.Roots}
+		return f, readSections(br, pos, f, visit)
+	case 2:
+	default:
+		return nil, fmt.Errorf("invalid car version: %d", header.Version)
+	}
+
+	var v2 carv2.Header
+	if _, err := v2.ReadFrom(br); err != nil {
+		return nil, fmt.Errorf("invalid CARv2 header: %w", err)
+	}
+	if err := skipTo(br, pos, v2.DataOffset); err != nil {
+		return nil, fmt.Errorf("invalid CARv2 data offset: %w", err)
+	}
+
+	payload := &countingReader{r: io.LimitReader(br, int64(v2.DataSize))}
+	pr := bufio.NewReader(payload)
+	payloadPos := func() int64 { return payload.n - int64(pr.Buffered()) }
+
+	inner, err := car.ReadHeader(pr)
 	if err != nil {
+		return nil, fmt.Errorf("invalid CARv2 data payload: %w", err)
+	}
+	if inner.Version != 1 {
+		return nil, fmt.Errorf("invalid CARv2 data payload version: %d", inner.Version)
+	}
+
+	f := &carFile{version: 2, roots: inner.Roots}
+	if err := readSections(pr, payloadPos, f, visit); err != nil {
 		return nil, err
 	}
+	if payload.n != int64(v2.DataSize) {
+		return nil, fmt.Errorf("the CARv2 data payload is %d bytes, expected %d", payload.n, v2.DataSize)
+	}
+
+	if !withIndex || !v2.HasIndex() {
+		return f, nil
+	}
+	if err := skipTo(br, pos, v2.IndexOffset); err != nil {
+		return nil, fmt.Errorf("invalid CARv2 index offset: %w", err)
+	}
+	if f.index, err = index.ReadFrom(br); err != nil {
+		return nil, fmt.Errorf("invalid CARv2 index: %w", err)
+	}
+	return f, nil
+}
 
-	// aggregate all blocks, ordered
-	var gotCIDs []cid.Cid
+// readSections reads the block sections of a CARv1, after its header.
+func readSections(br *bufio.Reader, pos func() int64, f *carFile, visit func(c cid.Cid, data []byte) error) error {
 	for {
-		block, err := cr.Next()
+		offset := pos()
+		c, data, err := util.ReadNode(br)
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := visit(c, data); err != nil {
+			return err
 		}
-		gotCIDs = append(gotCIDs, block.Cid())
+		f.cids = append(f.cids, c)
+		f.offsets = append(f.offsets, uint64(offset))
 	}
+}
 
-	return gotCIDs, nil
+// skipTo discards the bytes up to the offset, from the start of the CAR.
+func skipTo(br *bufio.Reader, pos func() int64, offset uint64) error {
+	skip := int64(offset) - pos()
+	if skip < 0 {
+		return fmt.Errorf("offset %d is before the current position %d", offset, pos())
+	}
+	_, err := io.CopyN(io.Discard, br, skip)
+	return err
 }
 
-func listAllRoots(carContent []byte) ([]cid.Cid, error) {
-	reader := bytes.NewReader(carContent)
-	bs, err := blockstore.NewReadOnly(reader, nil)
+// verifyIndex checks that the index has the offsets of all the blocks of the
+// data payload, except the identity ones, which are never indexed.
+func (f *carFile) verifyIndex() error {
+	if f.version != 2 {
+		return fmt.Errorf("a CARv%d has no index", f.version)
+	}
+	if f.index == nil {
+		return errors.New("the CARv2 has no index")
+	}
 
-	if err != nil {
-		return nil, err
+	for i, c := range f.cids {
+		if c.Prefix().MhType == multihash.IDENTITY {
+			continue
+		}
+		found := false
+		err := f.index.GetAll(c, func(offset uint64) bool {
+			found = offset == f.offsets[i]
+			return !found
+		})
+		if err != nil && !errors.Is(err, index.ErrNotFound) {
+			return fmt.Errorf("the index of block %s could not be read: %w", c, err)
+		}
+		if !found {
+			return fmt.Errorf("the index does not have the offset %d of block %s", f.offsets[i], c)
+		}
 	}
+	return nil
+}
 
-	return bs.Roots()
+// verifyBlock checks that the data of a block hashes to the multihash of its
+// CID.
+func verifyBlock(c cid.Cid, data []byte) error {
+	got, err := c.Prefix().Sum(data)
+	if err != nil {
+		return fmt.Errorf("block %s could not be hashed: %w", c, err)
+	}
+	if !bytes.Equal(got.Hash(), c.Hash()) {
+		return fmt.Errorf("block %s does not match its multihash, its data hashes to %s", c, got)
+	}
+	return nil
 }
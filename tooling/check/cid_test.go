@@ -68,6 +68,27 @@ func TestCIDEquals(t *testing.T) {
 	assert.False(t, CidSetEquals(a, b).Success)
 }
 
+func TestCidMultisetContains(t *testing.T) {
+	a := cids("hello", "world", "hello")
+	b := cids("hello", "hello")
+	assert.True(t, CidMultisetContains(a, b).Success)
+
+	a = cids("world", "hello")
+	b = cids("hello", "world", "hello")
+	output := CidMultisetContains(a, b)
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "2 times, got it 1 times")
+
+	a = cids("hello", "world", "hello")
+	b = cids("hello", "world")
+	assert.True(t, CidMultisetContains(a, b).Success)
+	assert.False(t, CidMultisetEquals(a, b).Success)
+
+	a = cids("hello", "hello", "world")
+	b = cids("world", "hello", "hello")
+	assert.True(t, CidMultisetEquals(a, b).Success)
+}
+
 func TestCidArrayEquals(t *testing.T) {
 	a := cids("hello")
 	b := cids()
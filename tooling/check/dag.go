@@ -0,0 +1,216 @@
+package check
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/multicodec"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	mc "github.com/multiformats/go-multicodec"
+
+	// The codecs register themselves in the multicodec registry.
+	_ "github.com/ipld/go-ipld-prime/codec/cbor"
+	_ "github.com/ipld/go-ipld-prime/codec/dagcbor"
+	_ "github.com/ipld/go-ipld-prime/codec/dagjson"
+	_ "github.com/ipld/go-ipld-prime/codec/json"
+)
+
+// maxDagDiffs is the number of differences listed in the reason of a failed
+// CheckIsDagNode.
+const maxDagDiffs = 20
+
+// CheckIsDagNode decodes a body with an IPLD codec, e.g. dag-json or dag-cbor,
+// and compares it with the expected node at the data model level: links and
+// bytes are compared as such, and the order of the map entries is ignored.
+type CheckIsDagNode struct {
+	codec    mc.Code
+	expected datamodel.Node
+}
+
+var _ Check[[]byte] = (*CheckIsDagNode)(nil)
+
+func IsDagNode(codecStr string) *CheckIsDagNode {
+	var codec mc.Code
+	if err := codec.Set(codecStr); err != nil {
+		panic(fmt.Errorf("invalid codec: %w", err))
+	}
+	if _, err := multicodec.LookupDecoder(uint64(codec)); err != nil {
+		panic(fmt.Errorf("invalid codec: %w", err))
+	}
+	return &CheckIsDagNode{codec: codec}
+}
+
+// Equals expects the body to decode to the node.
+func (c CheckIsDagNode) Equals(node datamodel.Node) *CheckIsDagNode {
+	c.expected = node
+	return &c
+}
+
+// EqualsEncoded expects the body to decode to the same node as the data,
+// encoded with the codec of the check, e.g. a fixture block.
+func (c CheckIsDagNode) EqualsEncoded(data []byte) *CheckIsDagNode {
+	node, err := c.decode(data)
+	if err != nil {
+		panic(fmt.Errorf("invalid %s data: %w", c.codec, err))
+	}
+	c.expected = node
+	return &c
+}
+
+func (c *CheckIsDagNode) decode(data []byte) (datamodel.Node, error) {
+	decoder, err := multicodec.LookupDecoder(uint64(c.codec))
+	if err != nil {
+		return nil, err
+	}
+	nb := basicnode.Prototype.Any.NewBuilder()
+	if err := decoder(nb, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return nb.Build(), nil
+}
+
+func (c *CheckIsDagNode) Check(v []byte) CheckOutput {
+	node, err := c.decode(v)
+	if err != nil {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("failed to decode the body as %s: %v", c.codec, err),
+		}
+	}
+
+	if c.expected == nil {
+		return CheckOutput{
+			Success: true,
+		}
+	}
+
+	// The nodes are equal when they have no differences, datamodel.DeepEqual
+	// would also compare the order of the map entries.
+	diffs := diffDagNodes("", c.expected, node, nil)
+	if len(diffs) == 0 {
+		return CheckOutput{
+			Success: true,
+		}
+	}
+	if len(diffs) > maxDagDiffs {
+		diffs = append(diffs[:maxDagDiffs], fmt.Sprintf("... and %d more differences", len(diffs)-maxDagDiffs))
+	}
+	return CheckOutput{
+		Success: false,
+		Reason:  fmt.Sprintf("the %s body does not match the expected node:\n%s", c.codec, strings.Join(diffs, "\n")),
+	}
+}
+
+func (c *CheckIsDagNode) String() string {
+	return fmt.Sprintf("is a %s node", c.codec)
+}
+
+// diffDagNodes lists the differences between two nodes, one per path, e.g.
+// /foo/1/bar: expected link X, got link Y. The path segments are escaped as
+// in a JSON pointer.
+func diffDagNodes(path string, expected, actual datamodel.Node, diffs []string) []string {
+	if datamodel.DeepEqual(expected, actual) {
+		return diffs
+	}
+
+	at := path
+	if at == "" {
+		at = "/"
+	}
+
+	if expected.Kind() != actual.Kind() {
+		return append(diffs, fmt.Sprintf("%s: expected %s, got %s", at, describeDagNode(expected), describeDagNode(actual)))
+	}
+
+	switch expected.Kind() {
+	case datamodel.Kind_Map:
+		it := expected.MapIterator()
+		for !it.Done() {
+			k, v, err := it.Next()
+			if err != nil {
+				return append(diffs, fmt.Sprintf("%s: %v", at, err))
+			}
+			key, _ := k.AsString()
+			got, err := actual.LookupByNode(k)
+			if err != nil {
+				diffs = append(diffs, fmt.Sprintf("%s: expected %s, got nothing", pointer(path, key), describeDagNode(v)))
+				continue
+			}
+			diffs = diffDagNodes(pointer(path, key), v, got, diffs)
+		}
+		it = actual.MapIterator()
+		for !it.Done() {
+			k, v, err := it.Next()
+			if err != nil {
+				return append(diffs, fmt.Sprintf("%s: %v", at, err))
+			}
+			key, _ := k.AsString()
+			if _, err := expected.LookupByNode(k); err != nil {
+				diffs = append(diffs, fmt.Sprintf("%s: expected nothing, got %s", pointer(path, key), describeDagNode(v)))
+			}
+		}
+		return diffs
+	case datamodel.Kind_List:
+		for i := int64(0); i < max(expected.Length(), actual.Length()); i++ {
+			item := pointer(path, fmt.Sprint(i))
+			e, _ := expected.LookupByIndex(i)
+			a, _ := actual.LookupByIndex(i)
+			switch {
+			case a == nil:
+				diffs = append(diffs, fmt.Sprintf("%s: expected %s, got nothing", item, describeDagNode(e)))
+			case e == nil:
+				diffs = append(diffs, fmt.Sprintf("%s: expected nothing, got %s", item, describeDagNode(a)))
+			default:
+				diffs = diffDagNodes(item, e, a, diffs)
+			}
+		}
+		return diffs
+	default:
+		return append(diffs, fmt.Sprintf("%s: expected %s, got %s", at, describeDagNode(expected), describeDagNode(actual)))
+	}
+}
+
+func pointer(path, segment string) string {
+	segment = strings.ReplaceAll(segment, "~", "~0")
+	segment = strings.ReplaceAll(segment, "/", "~1")
+	return path + "/" + segment
+}
+
+// describeDagNode returns the kind and the value of a node, only the size of
+// maps and lists.
+func describeDagNode(n datamodel.Node) string {
+	switch n.Kind() {
+	case datamodel.Kind_Map:
+		return fmt.Sprintf("map of %d entries", n.Length())
+	case datamodel.Kind_List:
+		return fmt.Sprintf("list of %d items", n.Length())
+	case datamodel.Kind_Null:
+		return "null"
+	case datamodel.Kind_Bool:
+		v, _ := n.AsBool()
+		return fmt.Sprintf("bool %t", v)
+	case datamodel.Kind_Int:
+		v, _ := n.AsInt()
+		return fmt.Sprintf("int %d", v)
+	case datamodel.Kind_Float:
+		v, _ := n.AsFloat()
+		return fmt.Sprintf("float %v", v)
+	case datamodel.Kind_String:
+		v, _ := n.AsString()
+		return fmt.Sprintf("string %q", v)
+	case datamodel.Kind_Bytes:
+		v, _ := n.AsBytes()
+		if len(v) > 32 {
+			return fmt.Sprintf("%d bytes 0x%s...", len(v), hex.EncodeToString(v[:32]))
+		}
+		return fmt.Sprintf("%d bytes 0x%s", len(v), hex.EncodeToString(v))
+	case datamodel.Kind_Link:
+		v, _ := n.AsLink()
+		return fmt.Sprintf("link %s", v)
+	default:
+		return n.Kind().String()
+	}
+}
@@ -0,0 +1,44 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDagNode(t *testing.T) {
+	hello := makeCID("hello")
+	world := makeCID("world")
+
+	expected := []byte(`{"foo":[1,{"bar":{"/":"` + hello.String() + `"}}],"data":{"/":{"bytes":"aGVsbG8"}},"s":"a"}`)
+	check := IsDagNode("dag-json").EqualsEncoded(expected)
+
+	// The order of the map entries and the whitespace are ignored.
+	assert.True(t, check.Check([]byte(`{"s": "a", "data": {"/": {"bytes": "aGVsbG8"}}, "foo": [1, {"bar": {"/": "`+hello.String()+`"}}]}`)).Success)
+	assert.True(t, IsDagNode("dag-json").Check(expected).Success)
+
+	output := check.Check([]byte(`{"foo":[1,{"bar":{"/":"` + world.String() + `"}},2],"data":{"/":{"bytes":"aGVsbA"}},"t":"a"}`))
+	assert.False(t, output.Success)
+	assert.Equal(t, `the dag-json body does not match the expected node:
+/foo/1/bar: expected link `+hello.String()+`, got link `+world.String()+`
+/foo/2: expected nothing, got int 2
+/data: expected 5 bytes 0x68656c6c6f, got 4 bytes 0x68656c6c
+/s: expected string "a", got nothing
+/t: expected nothing, got string "a"`, output.Reason)
+
+	output = check.Check([]byte(`[]`))
+	assert.False(t, output.Success)
+	assert.Equal(t, "the dag-json body does not match the expected node:\n/: expected map of 3 entries, got list of 0 items", output.Reason)
+
+	output = check.Check([]byte(`{`))
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "failed to decode the body as dag-json")
+
+	// The same node encoded with dag-cbor.
+	cbor := []byte{0xa1, 0x61, 0x61, 0x01}
+	assert.True(t, IsDagNode("dag-cbor").EqualsEncoded(cbor).Check(cbor).Success)
+	assert.Equal(t, "the dag-cbor body does not match the expected node:\n/a~1b: expected nothing, got int 1",
+		IsDagNode("dag-cbor").EqualsEncoded([]byte{0xa0}).Check([]byte{0xa1, 0x63, 0x61, 0x2f, 0x62, 0x01}).Reason)
+
+	assert.Panics(t, func() { IsDagNode("not-a-codec") })
+}
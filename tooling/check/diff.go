@@ -0,0 +1,276 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// maxDiffLines is the number of lines of a rendered diff, the rest is
+	// summarized.
+	maxDiffLines = 50
+	// maxDiffLineLength is the number of bytes shown of a line of a diff.
+	maxDiffLineLength = 160
+	// maxShortValue is the length of the values still quoted in the reason
+	// of a failed comparison, longer values are only shown in the diff.
+	maxShortValue = 80
+)
+
+// DiffFormat tells how the values of a Diff are compared and rendered.
+type DiffFormat int
+
+const (
+	// DiffText renders a unified diff of the lines.
+	DiffText DiffFormat = iota
+	// DiffJSON lists the differences by JSON pointer, e.g. /foo/0/bar.
+	DiffJSON
+	// DiffBinary renders the differing rows of a hex dump.
+	DiffBinary
+)
+
+// Diff is the expected and the actual values of a failed comparison, the
+// report renders it instead of quoting both values in the reason.
+type Diff struct {
+	Format   DiffFormat
+	Expected []byte
+	Actual   []byte
+}
+
+// isShort is true when the values are readable inline, in the reason.
+func isShort(values ...[]byte) bool {
+	for _, v := range values {
+		if len(v) > maxShortValue || bytes.ContainsAny(v, "\r\n") || !utf8.Valid(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Diff) String() string {
+	var lines []string
+	switch d.Format {
+	case DiffJSON:
+		lines = d.jsonLines()
+	case DiffBinary:
+		lines = d.binaryLines()
+	default:
+		lines = d.textLines()
+	}
+
+	if len(lines) > maxDiffLines {
+		lines = append(lines[:maxDiffLines], fmt.Sprintf("... %d more lines", len(lines)-maxDiffLines))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (d *Diff) textLines() []string {
+	expected, actual := string(d.Expected), string(d.Actual)
+
+	// A unified diff of a single long line would cut it before the
+	// difference, show the part of the lines where they differ instead.
+	if !strings.Contains(expected, "\n") && !strings.Contains(actual, "\n") {
+		at := commonPrefix(expected, actual)
+		return []string{
+			"--- expected",
+			"+++ actual",
+			fmt.Sprintf("@@ first difference at byte %d @@", at),
+			"-" + window(expected, at),
+			"+" + window(actual, at),
+		}
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(expected),
+		B:        difflib.SplitLines(actual),
+		FromFile: "expected",
+		ToFile:   "actual",
+		Context:  3,
+	})
+	if err != nil {
+		return []string{fmt.Sprintf("failed to diff the values: %v", err)}
+	}
+
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = truncate(line)
+	}
+	return lines
+}
+
+func (d *Diff) jsonLines() []string {
+	var expected, actual any
+	if err := json.Unmarshal(d.Expected, &expected); err != nil {
+		return (&Diff{Format: DiffText, Expected: d.Expected, Actual: d.Actual}).textLines()
+	}
+	if err := json.Unmarshal(d.Actual, &actual); err != nil {
+		return (&Diff{Format: DiffText, Expected: d.Expected, Actual: d.Actual}).textLines()
+	}
+	return diffJSON("", expected, actual, nil)
+}
+
+// diffJSON lists the differences between two decoded JSON values, one per
+// JSON pointer.
+func diffJSON(path string, expected, actual any, diffs []string) []string {
+	if reflect.DeepEqual(expected, actual) {
+		return diffs
+	}
+
+	at := path
+	if at == "" {
+		at = "/"
+	}
+
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, key := range slices.Sorted(maps.Keys(e)) {
+			if v, ok := a[key]; ok {
+				diffs = diffJSON(pointer(path, key), e[key], v, diffs)
+			} else {
+				diffs = append(diffs, fmt.Sprintf("%s: expected %s, got nothing", pointer(path, key), describeJSON(e[key])))
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(a)) {
+			if _, ok := e[key]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: expected nothing, got %s", pointer(path, key), describeJSON(a[key])))
+			}
+		}
+		return diffs
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			break
+		}
+		for i := range max(len(e), len(a)) {
+			item := pointer(path, fmt.Sprint(i))
+			switch {
+			case i >= len(a):
+				diffs = append(diffs, fmt.Sprintf("%s: expected %s, got nothing", item, describeJSON(e[i])))
+			case i >= len(e):
+				diffs = append(diffs, fmt.Sprintf("%s: expected nothing, got %s", item, describeJSON(a[i])))
+			default:
+				diffs = diffJSON(item, e[i], a[i], diffs)
+			}
+		}
+		return diffs
+	}
+
+	return append(diffs, fmt.Sprintf("%s: expected %s, got %s", at, describeJSON(expected), describeJSON(actual)))
+}
+
+func describeJSON(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return fmt.Sprintf("object of %d entries", len(v))
+	case []any:
+		return fmt.Sprintf("array of %d items", len(v))
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(b) > maxShortValue {
+		return string(b[:maxShortValue]) + "..."
+	}
+	return string(b)
+}
+
+// binaryLines renders the rows of 16 bytes of the hex dumps that differ.
+func (d *Diff) binaryLines() []string {
+	at := commonPrefix(string(d.Expected), string(d.Actual))
+	lines := []string{
+		"--- expected",
+		"+++ actual",
+		fmt.Sprintf("@@ expected %d bytes, got %d bytes, first difference at byte %d @@", len(d.Expected), len(d.Actual), at),
+	}
+
+	skipped := 0
+	for offset := at - at%16; offset < max(len(d.Expected), len(d.Actual)); offset += 16 {
+		e, a := row(d.Expected, offset), row(d.Actual, offset)
+		if bytes.Equal(e, a) {
+			continue
+		}
+		if len(lines)+2 >= maxDiffLines {
+			skipped++
+			continue
+		}
+		if e != nil {
+			lines = append(lines, "-"+hexRow(offset, e))
+		}
+		if a != nil {
+			lines = append(lines, "+"+hexRow(offset, a))
+		}
+	}
+	if skipped > 0 {
+		lines = append(lines, fmt.Sprintf("... %d more differing rows", skipped))
+	}
+	return lines
+}
+
+func row(b []byte, offset int) []byte {
+	if offset >= len(b) {
+		return nil
+	}
+	return b[offset:min(offset+16, len(b))]
+}
+
+// hexRow formats a row like hexdump -C.
+func hexRow(offset int, b []byte) string {
+	var hex, ascii strings.Builder
+	for i := range 16 {
+		if i == 8 {
+			hex.WriteByte(' ')
+		}
+		if i >= len(b) {
+			hex.WriteString("   ")
+			continue
+		}
+		fmt.Fprintf(&hex, " %02x", b[i])
+		if b[i] >= 0x20 && b[i] < 0x7f {
+			ascii.WriteByte(b[i])
+		} else {
+			ascii.WriteByte('.')
+		}
+	}
+	return fmt.Sprintf("%08x %s  |%s|", offset, hex.String(), ascii.String())
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// window returns the part of a line around the byte at.
+func window(s string, at int) string {
+	from := max(at-maxDiffLineLength/4, 0)
+	to := min(from+maxDiffLineLength, len(s))
+	w := s[from:to]
+	if from > 0 {
+		w = "..." + w
+	}
+	if to < len(s) {
+		w += "..."
+	}
+	return w
+}
+
+func truncate(line string) string {
+	if len(line) <= maxDiffLineLength {
+		return line
+	}
+	return line[:maxDiffLineLength] + fmt.Sprintf("... (%d more bytes)", len(line)-maxDiffLineLength)
+}
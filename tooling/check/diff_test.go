@@ -0,0 +1,97 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEqualShortValues(t *testing.T) {
+	output := IsEqual("foo").Check("bar")
+	assert.False(t, output.Success)
+	assert.Equal(t, "expected 'foo', got 'bar'", output.Reason)
+	assert.Nil(t, output.Diff)
+}
+
+func TestDiffText(t *testing.T) {
+	var expected, actual strings.Builder
+	for i := range 20 {
+		fmt.Fprintf(&expected, "<li>file-%d.txt</li>\n", i)
+		if i != 10 {
+			fmt.Fprintf(&actual, "<li>file-%d.txt</li>\n", i)
+		}
+	}
+
+	output := IsEqual(expected.String()).Check(actual.String())
+	assert.False(t, output.Success)
+	require.NotNil(t, output.Diff)
+	assert.Equal(t, DiffText, output.Diff.Format)
+	assert.Equal(t, `--- expected
++++ actual
+@@ -8,7 +8,6 @@
+ <li>file-7.txt</li>
+ <li>file-8.txt</li>
+ <li>file-9.txt</li>
+-<li>file-10.txt</li>
+ <li>file-11.txt</li>
+ <li>file-12.txt</li>
+ <li>file-13.txt</li>`, output.Diff.String())
+}
+
+func TestDiffTextSingleLine(t *testing.T) {
+	expected := strings.Repeat("a", 500) + "b" + strings.Repeat("a", 500)
+	actual := strings.Repeat("a", 500) + "c" + strings.Repeat("a", 500)
+
+	diff := (&Diff{Format: DiffText, Expected: []byte(expected), Actual: []byte(actual)}).String()
+	lines := strings.Split(diff, "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "@@ first difference at byte 500 @@", lines[2])
+	assert.Contains(t, lines[3], "ab")
+	assert.Contains(t, lines[4], "ac")
+	assert.Less(t, len(lines[3]), 200)
+}
+
+func TestDiffTruncated(t *testing.T) {
+	expected := strings.Repeat("a\n", 200)
+	actual := strings.Repeat("b\n", 200)
+
+	diff := (&Diff{Format: DiffText, Expected: []byte(expected), Actual: []byte(actual)}).String()
+	lines := strings.Split(diff, "\n")
+	assert.Len(t, lines, maxDiffLines+1)
+	assert.Regexp(t, `^\.\.\. \d+ more lines$`, lines[maxDiffLines])
+}
+
+func TestDiffJSON(t *testing.T) {
+	expected := []byte(`{"name": "foo", "links": [{"/": "bafy1"}, {"/": "bafy2"}], "size": 1024, "description": "a long description of the value to compare"}`)
+	actual := []byte(`{"name": "foo", "links": [{"/": "bafy1"}], "size": 1023, "description": "a long description of the value to compare", "extra/key": true}`)
+
+	output := IsJSONEqual(expected).Check(actual)
+	assert.False(t, output.Success)
+	require.NotNil(t, output.Diff)
+	assert.Equal(t, `/links/1: expected object of 1 entries, got nothing
+/size: expected 1024, got 1023
+/extra~1key: expected nothing, got true`, output.Diff.String())
+}
+
+func TestDiffBinary(t *testing.T) {
+	expected := bytes.Repeat([]byte{0xff, 0x00}, 64)
+	actual := bytes.Clone(expected)
+	actual[40] = 0x01
+	actual = actual[:120]
+
+	output := IsEqualBytes(expected).Check(actual)
+	assert.False(t, output.Success)
+	require.NotNil(t, output.Diff)
+	assert.Equal(t, DiffBinary, output.Diff.Format)
+	assert.Equal(t, `--- expected
++++ actual
+@@ expected 128 bytes, got 120 bytes, first difference at byte 40 @@
+-00000020  ff 00 ff 00 ff 00 ff 00  ff 00 ff 00 ff 00 ff 00  |................|
++00000020  ff 00 ff 00 ff 00 ff 00  01 00 ff 00 ff 00 ff 00  |................|
+-00000070  ff 00 ff 00 ff 00 ff 00  ff 00 ff 00 ff 00 ff 00  |................|
++00000070  ff 00 ff 00 ff 00 ff 00                           |........|`, output.Diff.String())
+}
@@ -0,0 +1,78 @@
+package check
+
+import (
+	"fmt"
+	"maps"
+	"mime"
+	"slices"
+	"strings"
+)
+
+// CheckIsMediaType parses a Content-Type and checks its media type and
+// parameters, e.g. application/vnd.ipld.car; version=1; order=dfs; dups=n.
+type CheckIsMediaType struct {
+	mediaType string
+	params    map[string][]string
+}
+
+var _ Check[string] = (*CheckIsMediaType)(nil)
+
+func IsMediaType(mediaType string) *CheckIsMediaType {
+	return &CheckIsMediaType{
+		mediaType: strings.ToLower(mediaType),
+		params:    map[string][]string{},
+	}
+}
+
+// WithParam expects the parameter to be one of the values, or to be set to
+// any value when no value is given.
+func (c CheckIsMediaType) WithParam(key string, values ...string) *CheckIsMediaType {
+	c.params = maps.Clone(c.params)
+	c.params[strings.ToLower(key)] = values
+	return &c
+}
+
+func (c *CheckIsMediaType) Check(v string) CheckOutput {
+	mediaType, params, err := mime.ParseMediaType(v)
+	if err != nil {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("failed to parse the media type '%s': %v", v, err),
+		}
+	}
+
+	if mediaType != c.mediaType {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("expected the media type '%s', got '%s'", c.mediaType, v),
+		}
+	}
+
+	for key, values := range c.params {
+		value, ok := params[key]
+		if !ok {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("expected the parameter '%s' in '%s'", key, v),
+			}
+		}
+		if len(values) > 0 && !slices.Contains(values, value) {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("expected the parameter '%s' to be one of %v, got '%s'", key, values, value),
+			}
+		}
+	}
+
+	return CheckOutput{
+		Success: true,
+	}
+}
+
+func (c *CheckIsMediaType) String() string {
+	s := c.mediaType
+	for _, key := range slices.Sorted(maps.Keys(c.params)) {
+		s += fmt.Sprintf("; %s=%s", key, strings.Join(c.params[key], "|"))
+	}
+	return fmt.Sprintf("is media type '%s'", s)
+}
@@ -0,0 +1,38 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMediaType(t *testing.T) {
+	car := IsMediaType("application/vnd.ipld.car")
+	dfs := car.WithParam("version", "1").WithParam("order", "dfs").WithParam("dups", "y", "n")
+
+	assert.True(t, car.Check("application/vnd.ipld.car").Success)
+	assert.True(t, car.Check("Application/vnd.ipld.CAR; version=1").Success)
+	assert.True(t, dfs.Check("application/vnd.ipld.car; version=1; order=dfs; dups=n").Success)
+	assert.True(t, dfs.Check(`application/vnd.ipld.car;dups="y";order=dfs;version=1`).Success)
+	assert.True(t, car.WithParam("order").Check("application/vnd.ipld.car; order=unk").Success)
+
+	output := car.Check("application/vnd.ipld.raw")
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "expected the media type 'application/vnd.ipld.car'")
+
+	output = car.Check("application/vnd.ipld.car; version")
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "failed to parse the media type")
+
+	output = dfs.Check("application/vnd.ipld.car; version=1; order=unk; dups=n")
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "expected the parameter 'order' to be one of [dfs], got 'unk'")
+
+	output = dfs.Check("application/vnd.ipld.car; version=1; order=dfs")
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "expected the parameter 'dups'")
+
+	// WithParam does not modify the check it is called on.
+	assert.True(t, car.Check("application/vnd.ipld.car; order=unk").Success)
+	assert.Equal(t, "is media type 'application/vnd.ipld.car; dups=y|n; order=dfs; version=1'", dfs.String())
+}
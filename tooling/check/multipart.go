@@ -0,0 +1,156 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// Response is a buffered response body with the headers of the response, for
+// the body checks depending on a header, e.g. the boundary of a multipart
+// Content-Type.
+type Response struct {
+	Header http.Header
+	Body   []byte
+}
+
+// multipartPart is an expected part of a multipart/byteranges body.
+type multipartPart struct {
+	contentRange string
+	data         []byte
+}
+
+// CheckIsMultipartByteranges parses a multipart/byteranges response, with the
+// boundary of its Content-Type, and compares its parts, in order, with the
+// expected ones, as in RFC 9110 section 14.6.
+type CheckIsMultipartByteranges struct {
+	contentType string
+	parts       []multipartPart
+}
+
+var _ Check[Response] = (*CheckIsMultipartByteranges)(nil)
+
+func IsMultipartByteranges() *CheckIsMultipartByteranges {
+	return &CheckIsMultipartByteranges{}
+}
+
+// WithContentType expects every part to have the Content-Type, the one of the
+// representation: the same media type and parameters, e.g. "text/plain;
+// charset=UTF-8" is "text/plain;charset=utf-8".
+func (c CheckIsMultipartByteranges) WithContentType(contentType string) *CheckIsMultipartByteranges {
+	c.contentType = contentType
+	return &c
+}
+
+// WithPart expects a part with the Content-Range, e.g. "bytes 0-3/31", and the
+// data, after the parts given before.
+func (c CheckIsMultipartByteranges) WithPart(contentRange string, data []byte) *CheckIsMultipartByteranges {
+	c.parts = append(c.parts[:len(c.parts):len(c.parts)], multipartPart{contentRange: contentRange, data: data})
+	return &c
+}
+
+func (c *CheckIsMultipartByteranges) Check(v Response) CheckOutput {
+	mediaType, params, err := mime.ParseMediaType(v.Header.Get("Content-Type"))
+	if err != nil {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("failed to parse the Content-Type '%s': %v", v.Header.Get("Content-Type"), err),
+		}
+	}
+	if mediaType != "multipart/byteranges" {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("expected the media type 'multipart/byteranges', got '%s'", mediaType),
+		}
+	}
+	if params["boundary"] == "" {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("expected a boundary in the Content-Type '%s'", v.Header.Get("Content-Type")),
+		}
+	}
+
+	r := multipart.NewReader(bytes.NewReader(v.Body), params["boundary"])
+	for i := 0; ; i++ {
+		part, err := r.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			if i != len(c.parts) {
+				return CheckOutput{
+					Success: false,
+					Reason:  fmt.Sprintf("expected %d parts, got %d", len(c.parts), i),
+				}
+			}
+			return CheckOutput{
+				Success: true,
+			}
+		}
+		if err != nil {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("failed to read the part %d: %v", i, err),
+			}
+		}
+		if i >= len(c.parts) {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("expected %d parts, got a part %d with the Content-Range '%s'", len(c.parts), i, part.Header.Get("Content-Range")),
+			}
+		}
+
+		expected := c.parts[i]
+		if c.contentType != "" && !isSameMediaType(part.Header.Get("Content-Type"), c.contentType) {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("expected the part %d to have the Content-Type '%s', got '%s'", i, c.contentType, part.Header.Get("Content-Type")),
+			}
+		}
+		if got := part.Header.Get("Content-Range"); got != expected.contentRange {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("expected the part %d to have the Content-Range '%s', got '%s'", i, expected.contentRange, got),
+			}
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return CheckOutput{
+				Success: false,
+				Reason:  fmt.Sprintf("failed to read the part %d: %v", i, err),
+			}
+		}
+		if output := IsEqualBytes(expected.data).Check(data); !output.Success {
+			output.Reason = fmt.Sprintf("of the part %d (%s) %s", i, expected.contentRange, output.Reason)
+			return output
+		}
+	}
+}
+
+// isSameMediaType compares two Content-Type values once parsed, the types and
+// the names of the parameters are case-insensitive, as are the charsets, see
+// RFC 9110 section 8.3.1.
+func isSameMediaType(a, b string) bool {
+	aType, aParams, err := mime.ParseMediaType(a)
+	if err != nil {
+		return false
+	}
+	bType, bParams, err := mime.ParseMediaType(b)
+	if err != nil {
+		return false
+	}
+	for _, params := range []map[string]string{aParams, bParams} {
+		if charset, ok := params["charset"]; ok {
+			params["charset"] = strings.ToLower(charset)
+		}
+	}
+	return aType == bType && maps.Equal(aParams, bParams)
+}
+
+func (c *CheckIsMultipartByteranges) String() string {
+	return fmt.Sprintf("is multipart/byteranges with %d parts", len(c.parts))
+}
@@ -0,0 +1,140 @@
+package check
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func multipartResponse(t *testing.T, parts ...[2]string) Response {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {"text/plain"},
+			"Content-Range": {p[0]},
+		})
+		assert.NoError(t, err)
+		_, err = pw.Write([]byte(p[1]))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	return Response{
+		Header: http.Header{"Content-Type": {"multipart/byteranges; boundary=" + w.Boundary()}},
+		Body:   buf.Bytes(),
+	}
+}
+
+func TestIsMultipartByteranges(t *testing.T) {
+	res := multipartResponse(t, [2]string{"bytes 6-9/31", "worl"}, [2]string{"bytes 0-4/31", "hello"})
+
+	c := IsMultipartByteranges().
+		WithContentType("text/plain").
+		WithPart("bytes 6-9/31", []byte("worl")).
+		WithPart("bytes 0-4/31", []byte("hello"))
+	output := c.Check(res)
+	assert.True(t, output.Success, output.Reason)
+
+	// The parts are compared in order.
+	output = IsMultipartByteranges().
+		WithPart("bytes 0-4/31", []byte("hello")).
+		WithPart("bytes 6-9/31", []byte("worl")).
+		Check(res)
+	assert.False(t, output.Success)
+	assert.Equal(t, "expected the part 0 to have the Content-Range 'bytes 0-4/31', got 'bytes 6-9/31'", output.Reason)
+
+	output = IsMultipartByteranges().WithContentType("Text/Plain").WithPart("bytes 6-9/31", []byte("worl")).WithPart("bytes 0-4/31", []byte("hello")).Check(res)
+	assert.True(t, output.Success, output.Reason)
+
+	output = IsMultipartByteranges().WithContentType("text/html").WithPart("bytes 6-9/31", []byte("worl")).Check(res)
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "Content-Type 'text/html', got 'text/plain'")
+}
+
+func TestIsMultipartByterangesParts(t *testing.T) {
+	res := multipartResponse(t, [2]string{"bytes 0-4/31", "hello"})
+
+	output := IsMultipartByteranges().WithPart("bytes 0-4/31", []byte("hallo")).Check(res)
+	assert.False(t, output.Success)
+	assert.Equal(t, "of the part 0 (bytes 0-4/31) expected \"hallo\", got \"hello\"", output.Reason)
+
+	output = IsMultipartByteranges().
+		WithPart("bytes 0-4/31", []byte("hello")).
+		WithPart("bytes 6-9/31", []byte("worl")).
+		Check(res)
+	assert.False(t, output.Success)
+	assert.Equal(t, "expected 2 parts, got 1", output.Reason)
+
+	output = IsMultipartByteranges().Check(res)
+	assert.False(t, output.Success)
+	assert.Equal(t, "expected 0 parts, got a part 0 with the Content-Range 'bytes 0-4/31'", output.Reason)
+}
+
+func TestIsSameMediaType(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"text/plain", "text/plain", true},
+		{"text/plain; charset=utf-8", "text/plain;charset=utf-8", true},
+		{"text/plain; charset=utf-8", "Text/Plain; Charset=UTF-8", true},
+		{`text/plain; charset="utf-8"`, "text/plain; charset=utf-8", true},
+		{"text/plain; charset=utf-8", "text/plain", false},
+		{"text/plain", "text/html", false},
+		{"application/vnd.ipld.car; version=1", "application/vnd.ipld.car; version=2", false},
+		{"text/plain; charset", "text/plain; charset", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isSameMediaType(tt.a, tt.b), "%s and %s", tt.a, tt.b)
+	}
+}
+
+func TestIsMultipartByterangesContentType(t *testing.T) {
+	res := multipartResponse(t, [2]string{"bytes 0-4/31", "hello"})
+
+	res.Header.Set("Content-Type", "text/plain")
+	output := IsMultipartByteranges().Check(res)
+	assert.False(t, output.Success)
+	assert.Equal(t, "expected the media type 'multipart/byteranges', got 'text/plain'", output.Reason)
+
+	res.Header.Set("Content-Type", "multipart/byteranges")
+	output = IsMultipartByteranges().Check(res)
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "expected a boundary")
+}
+
+func TestIsMultipartByterangesServeContent(t *testing.T) {
+	data := "hello gateway conformance world"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(data))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	assert.NoError(t, err)
+	req.Header.Set("Range", "bytes=-5,6-12,0-4")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+
+	output := IsMultipartByteranges().
+		WithContentType("text/plain; charset=utf-8").
+		WithPart("bytes 26-30/31", []byte(data[26:31])).
+		WithPart("bytes 6-12/31", []byte(data[6:13])).
+		WithPart("bytes 0-4/31", []byte(data[0:5])).
+		Check(Response{Header: res.Header, Body: body})
+	assert.True(t, output.Success, output.Reason)
+}
@@ -0,0 +1,78 @@
+package check
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// ReaderCheck is implemented by the checks able to validate a stream, e.g. a
+// CAR block by block, without buffering it.
+type ReaderCheck interface {
+	CheckReader(r io.Reader) CheckOutput
+}
+
+// CheckStreaming validates a body as a stream, see Streaming.
+type CheckStreaming struct {
+	Check_ ReaderCheck
+}
+
+var _ Check[io.Reader] = CheckStreaming{}
+
+// Streaming checks a response body as it is read, instead of buffering it
+// first: e.g. Body(check.Streaming(check.IsCar().HasBlock(cid))). The body is
+// consumed by the check, it can not be combined with another body check.
+//
+// It panics with an IsCar().SatisfiesRequest(...) check, which keeps all the
+// blocks of the CAR in memory, use SatisfiesRequestOf(fixture, ...) instead.
+func Streaming(c ReaderCheck) CheckStreaming {
+	if car, ok := c.(*CheckIsCarFile); ok && car.request != nil && car.requestDAG == nil {
+		panic("SatisfiesRequest keeps the whole CAR in memory, it can not be used with Streaming, use SatisfiesRequestOf")
+	}
+	return CheckStreaming{Check_: c}
+}
+
+func (c CheckStreaming) Check(r io.Reader) CheckOutput {
+	return c.Check_.CheckReader(r)
+}
+
+// CheckHashEquals hashes a stream and compares its digest.
+type CheckHashEquals struct {
+	newHash func() hash.Hash
+	digest  []byte
+}
+
+var _ Check[io.Reader] = (*CheckHashEquals)(nil)
+
+// HashEquals checks that a stream hashes to the digest, e.g.
+// HashEquals(sha256.New, digest) for a large file.
+func HashEquals(newHash func() hash.Hash, digest []byte) *CheckHashEquals {
+	return &CheckHashEquals{
+		newHash: newHash,
+		digest:  digest,
+	}
+}
+
+func (c *CheckHashEquals) Check(r io.Reader) CheckOutput {
+	h := c.newHash()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("failed to read the content after %d bytes: %v", n, err),
+		}
+	}
+
+	if got := h.Sum(nil); !bytes.Equal(got, c.digest) {
+		return CheckOutput{
+			Success: false,
+			Reason:  fmt.Sprintf("expected a digest of %s, got %s for %d bytes", hex.EncodeToString(c.digest), hex.EncodeToString(got), n),
+		}
+	}
+
+	return CheckOutput{
+		Success: true,
+	}
+}
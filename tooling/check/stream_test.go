@@ -0,0 +1,66 @@
+package check
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamingCar(t *testing.T) {
+	f, err := os.Open("./_fixtures/dag.car")
+	require.NoError(t, err)
+	defer f.Close()
+
+	c := Streaming(IsCar().
+		HasBlocks("bafybeidlbwbu73tbjr3atntjz4lq5ego5w2uyof35vvwcnheaftzi3rndu",
+			"bafkreihdhgb5vyuqu7jssreyo3h567obewtqq37fi5hr2w4um5icacry7m").
+		HasRoot("bafybeidlbwbu73tbjr3atntjz4lq5ego5w2uyof35vvwcnheaftzi3rndu"))
+	assert.True(t, c.Check(f).Success)
+
+	output := Streaming(IsCar()).Check(strings.NewReader("not a car"))
+	assert.False(t, output.Success)
+
+	assert.Panics(t, func() {
+		Streaming(IsCar().SatisfiesRequest("/ipfs/bafybeidlbwbu73tbjr3atntjz4lq5ego5w2uyof35vvwcnheaftzi3rndu", "all", ""))
+	})
+}
+
+func TestStreamingTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range map[string]string{"a.txt": "hello", "big.bin": strings.Repeat("x", 1<<20)} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	c := Streaming(IsTarFile().HasFile("big.bin").HasFileWithContent("a.txt", "hello"))
+	assert.True(t, c.Check(bytes.NewReader(buf.Bytes())).Success)
+
+	c = Streaming(IsTarFile().HasFileWithContent("a.txt", "bye"))
+	assert.False(t, c.Check(bytes.NewReader(buf.Bytes())).Success)
+}
+
+func TestHashEquals(t *testing.T) {
+	content := strings.Repeat("gateway", 1<<16)
+	digest := sha256.Sum256([]byte(content))
+
+	assert.True(t, HashEquals(sha256.New, digest[:]).Check(strings.NewReader(content)).Success)
+
+	output := HashEquals(sha256.New, digest[:]).Check(strings.NewReader(content[1:]))
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "for 458751 bytes")
+
+	output = HashEquals(sha256.New, digest[:]).Check(io.MultiReader(strings.NewReader("a"), iotest.ErrReader(io.ErrUnexpectedEOF)))
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "after 1 bytes")
+}
@@ -11,6 +11,7 @@ import (
 )
 
 var _ Check[[]byte] = &CheckIsTarFile{}
+var _ ReaderCheck = &CheckIsTarFile{}
 
 type CheckIsTarFile struct {
 	fileNames        []string
@@ -36,7 +37,12 @@ func (c *CheckIsTarFile) HasFileWithContent(fileName, content string) *CheckIsTa
 }
 
 func (c *CheckIsTarFile) Check(v []byte) CheckOutput {
-	r := bytes.NewReader(v)
+	return c.CheckReader(bytes.NewReader(v))
+}
+
+// CheckReader checks a TAR stream entry by entry, only the content of the
+// files with an expected content is buffered.
+func (c *CheckIsTarFile) CheckReader(r io.Reader) CheckOutput {
 	tr := tar.NewReader(r)
 
 	searchedFiles := make(map[string]bool)
@@ -62,8 +68,13 @@ func (c *CheckIsTarFile) Check(v []byte) CheckOutput {
 			}
 		}
 
+		_, withContent := c.filesWithContent[hdr.Name]
+		var w io.Writer = io.Discard
 		buf := new(bytes.Buffer)
-		_, err = io.Copy(buf, tr)
+		if withContent {
+			w = buf
+		}
+		_, err = io.Copy(w, tr)
 		if err != nil {
 			return CheckOutput{
 				Success: false,
@@ -77,7 +88,7 @@ func (c *CheckIsTarFile) Check(v []byte) CheckOutput {
 			searchedFiles[hdr.Name] = true
 		}
 
-		if _, ok := c.filesWithContent[hdr.Name]; ok {
+		if withContent {
 			content := buf.String()
 
 			if content != c.filesWithContent[hdr.Name] {
@@ -0,0 +1,94 @@
+package tooling
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	// Groups selects the tests of these groups (see LogTestGroup), "Other"
+	// selects the tests without a group. Empty to select every test.
+	Groups []string
+	// SpecURLs selects the tests covering a spec (see LogSpecs) starting
+	// with one of these URLs. Empty to select every test.
+	SpecURLs []string
+
+	filterMu   sync.Mutex
+	testGroups = map[string]string{}
+	testSpecs  = map[string][]string{}
+)
+
+func recordGroup(t *testing.T, group string) {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	testGroups[t.Name()] = group
+}
+
+func recordSpecs(t *testing.T, specs []string) {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	testSpecs[t.Name()] = append(testSpecs[t.Name()], specs...)
+}
+
+// groupSelected returns true when the group is selected by Groups.
+func groupSelected(group string) bool {
+	if len(Groups) == 0 {
+		return true
+	}
+	if group == "" {
+		group = "Other"
+	}
+	return slices.ContainsFunc(Groups, func(g string) bool {
+		return strings.EqualFold(g, group)
+	})
+}
+
+// SpecURLMatches returns true when the spec is the URL, or a section or a
+// child page of the URL. The scheme is optional on both sides.
+func SpecURLMatches(spec, url string) bool {
+	spec = strings.TrimPrefix(strings.TrimPrefix(spec, "https://"), "http://")
+	url = strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+	if !strings.HasPrefix(spec, url) {
+		return false
+	}
+	rest := spec[len(url):]
+	return rest == "" || strings.HasSuffix(url, "/") || strings.HasSuffix(url, "#") || rest[0] == '/' || rest[0] == '#'
+}
+
+// SkipUnselected skips the test when its group, or the specs it and its
+// ancestors logged (plus the given specs), are not selected by Groups and
+// SpecURLs.
+func SkipUnselected(t *testing.T, specs ...string) {
+	t.Helper()
+
+	if len(Groups) == 0 && len(SpecURLs) == 0 {
+		return
+	}
+
+	filterMu.Lock()
+	top, _, _ := strings.Cut(t.Name(), "/")
+	group := testGroups[top]
+	for name := t.Name(); ; {
+		specs = append(specs, testSpecs[name]...)
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	filterMu.Unlock()
+
+	if !groupSelected(group) {
+		t.Skipf("skipping test, group %q is not selected", group)
+	}
+
+	if len(SpecURLs) > 0 && !slices.ContainsFunc(specs, func(spec string) bool {
+		return slices.ContainsFunc(SpecURLs, func(url string) bool {
+			return SpecURLMatches(spec, url)
+		})
+	}) {
+		t.Skipf("skipping test, none of its specs %v is selected", specs)
+	}
+}
@@ -0,0 +1,51 @@
+package tooling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecURLMatches(t *testing.T) {
+	const spec = "https://specs.ipfs.tech/http-gateways/path-gateway/#if-none-match-request-header"
+
+	assert.True(t, SpecURLMatches(spec, spec))
+	assert.True(t, SpecURLMatches(spec, "https://specs.ipfs.tech/http-gateways/path-gateway/"))
+	assert.True(t, SpecURLMatches(spec, "https://specs.ipfs.tech/http-gateways/path-gateway"))
+	assert.True(t, SpecURLMatches(spec, "specs.ipfs.tech/http-gateways"))
+	assert.True(t, SpecURLMatches("specs.ipfs.tech/http-gateways/path-gateway/", "https://specs.ipfs.tech/http-gateways/"))
+
+	assert.False(t, SpecURLMatches(spec, "https://specs.ipfs.tech/http-gateways/path-gateway/#if-none-match"))
+	assert.False(t, SpecURLMatches(spec, "https://specs.ipfs.tech/http-gateways/path"))
+	assert.False(t, SpecURLMatches(spec, "https://specs.ipfs.tech/http-gateways/subdomain-gateway/"))
+}
+
+func TestSkipUnselected(t *testing.T) {
+	defer func() { Groups, SpecURLs = nil, nil }()
+
+	selected := func(groups, specURLs []string, group string, specs ...string) bool {
+		Groups, SpecURLs = groups, specURLs
+		// The group is logged by the top-level test.
+		testGroups = map[string]string{}
+		if group != "" {
+			recordGroup(t, group)
+		}
+		ran := false
+		t.Run("Sub", func(t *testing.T) {
+			SkipUnselected(t, specs...)
+			ran = true
+		})
+		return ran
+	}
+
+	assert.True(t, selected(nil, nil, ""))
+	assert.True(t, selected([]string{"unixfs"}, nil, "UnixFS"))
+	assert.False(t, selected([]string{"CORS"}, nil, "UnixFS"))
+	assert.True(t, selected([]string{"Other"}, nil, ""))
+	assert.False(t, selected([]string{"CORS"}, nil, ""))
+
+	specURLs := []string{"https://specs.ipfs.tech/http-gateways/trustless-gateway/"}
+	assert.True(t, selected(nil, specURLs, "", "https://specs.ipfs.tech/http-gateways/trustless-gateway/#dag-scope-request-query-parameter"))
+	assert.False(t, selected(nil, specURLs, "", "https://specs.ipfs.tech/http-gateways/path-gateway/"))
+	assert.False(t, selected(nil, specURLs, ""))
+}
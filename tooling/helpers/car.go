@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/ipfs/gateway-conformance/tooling/check"
 	"github.com/ipfs/gateway-conformance/tooling/test"
 )
 
@@ -33,7 +34,7 @@ func carResponseHeaders() []test.HeaderBuilder {
 			Equals("none"),
 		test.Header("Content-Type").
 			Hint("Expected content type to be application/vnd.ipld.car").
-			Contains("application/vnd.ipld.car"),
+			Satisfies(check.IsMediaType("application/vnd.ipld.car")),
 		test.Header("Content-Disposition").
 			Hint(`Expected content disposition to be attachment; filename="*.car"`).
 			Matches(`attachment; filename=".*\.car"`),
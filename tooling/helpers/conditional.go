@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/test"
+)
+
+const (
+	ifNoneMatchSpec     = "https://specs.ipfs.tech/http-gateways/path-gateway/#if-none-match-request-header"
+	ifModifiedSinceSpec = "https://specs.ipfs.tech/http-gateways/path-gateway/#if-modified-since-request-header"
+)
+
+// ConditionalRequestTransforms adds, after every GET test expecting a 200
+// response, a test sending the request again with the Etag of the response in
+// If-None-Match (strong, weak, wildcard and list forms), and its Last-Modified
+// in If-Modified-Since, expecting a 304 Not Modified without body. The
+// requests depending on a header the gateway did not send are skipped.
+func ConditionalRequestTransforms(t *testing.T, sts test.SugarTests) test.SugarTests {
+	t.Helper()
+
+	var out test.SugarTests
+	for _, st := range sts {
+		out = append(out, st)
+		if isConditionalCandidate(st) {
+			out = append(out, conditionalRequests(st))
+		}
+	}
+	return out
+}
+
+// isConditionalCandidate returns true for the tests of a single GET request
+// expecting a 200 response, without conditional or range headers.
+func isConditionalCandidate(st test.SugarTest) bool {
+	if len(st.Requests) > 0 || len(st.Steps) > 0 {
+		return false
+	}
+	if st.Request.Method_ != "" && st.Request.Method_ != http.MethodGet {
+		return false
+	}
+	for key := range st.Request.Headers_ {
+		switch http.CanonicalHeaderKey(key) {
+		case "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range", "Range":
+			return false
+		}
+	}
+	expect, ok := st.Response.(test.ExpectBuilder)
+	return ok && expect.StatusCode_ == http.StatusOK
+}
+
+func conditionalRequests(st test.SugarTest) test.SugarTest {
+	notModified := func(name, spec, header, value string, args ...any) test.StepBuilder {
+		return test.Step(name).
+			Request(st.Request.Clone().Header(header, value, args...)).
+			Response(test.Expect().
+				Status(http.StatusNotModified).
+				Body("").
+				Spec(spec))
+	}
+
+	return test.SugarTest{
+		Name:  fmt.Sprintf("%s (conditional requests)", st.Name),
+		Hint:  joinHints(st.Hint, "A request with the validators of a previous response returns 304 Not Modified"),
+		Spec:  st.Spec,
+		Specs: st.Specs,
+		Steps: []test.StepBuilder{
+			test.Step("GET").
+				Request(st.Request.Clone()).
+				Response(test.Expect().Status(http.StatusOK)).
+				Capture(
+					// The opaque-tag, without the weakness indicator.
+					test.Capture("etag").FromHeader("Etag").Matches(`^(?:W/)?("[^"]*")$`).Optional(),
+					test.Capture("lastModified").FromHeader("Last-Modified").Optional(),
+				),
+			notModified("If-None-Match with the Etag", ifNoneMatchSpec, "If-None-Match", "{{etag}}", test.Var("etag")),
+			notModified("If-None-Match with the weak Etag", ifNoneMatchSpec, "If-None-Match", "W/{{etag}}", test.Var("etag")),
+			notModified("If-None-Match with a list of Etags", ifNoneMatchSpec, "If-None-Match", `"unrelated", {{etag}}, W/"other"`, test.Var("etag")),
+			notModified("If-None-Match with a wildcard", ifNoneMatchSpec, "If-None-Match", "*"),
+			notModified("If-Modified-Since with the Last-Modified", ifModifiedSinceSpec, "If-Modified-Since", "{{lastModified}}", test.Var("lastModified")),
+		},
+	}
+}
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/test"
+)
+
+const headSpec = "https://specs.ipfs.tech/http-gateways/path-gateway/#head-ipfs-cid-path-params"
+
+// headBodyDependentHeaders depend on the body sent, a HEAD response may leave
+// them out: e.g. Content-Length is only a MAY, see RFC 9110 section 8.6.
+var headBodyDependentHeaders = []string{"Content-Length", "Transfer-Encoding", "Trailer"}
+
+// headRepresentationHeaders describe the content the request is about, a HEAD
+// response has the ones of the GET response. The headers of each message,
+// e.g. Date, Age or Via, may differ.
+var headRepresentationHeaders = []string{"Content-Type", "Etag", "Cache-Control", "Content-Disposition"}
+
+func isHEADRepresentationHeader(key string) bool {
+	return slices.Contains(headRepresentationHeaders, key) || strings.HasPrefix(key, "X-Ipfs-")
+}
+
+// HEADRequestTransforms adds, after every GET test, a scenario sending the
+// GET request and the same request as a HEAD: the HEAD response must have the
+// status and headers the test expects, no body, the representation headers of
+// the GET response, and its Content-Length when a 2xx HEAD response has one.
+func HEADRequestTransforms(t *testing.T, sts test.SugarTests) test.SugarTests {
+	t.Helper()
+
+	var out test.SugarTests
+	for _, st := range sts {
+		out = append(out, st)
+		if isHEADCandidate(st) {
+			out = append(out, headRequest(st))
+		}
+	}
+	return out
+}
+
+// isHEADCandidate returns true for the tests of a single GET request with an
+// expected response, without range headers: a Range only applies to GET, see
+// RFC 9110 section 14.2.
+func isHEADCandidate(st test.SugarTest) bool {
+	if len(st.Requests) > 0 || len(st.Steps) > 0 || st.Response == nil {
+		return false
+	}
+	if st.Request.Method_ != "" && st.Request.Method_ != http.MethodGet {
+		return false
+	}
+	for key := range st.Request.Headers_ {
+		switch http.CanonicalHeaderKey(key) {
+		case "If-Range", "Range":
+			return false
+		}
+	}
+	return true
+}
+
+func headRequest(st test.SugarTest) test.SugarTest {
+	get := &getResponse{}
+	return test.SugarTest{
+		Name:  fmt.Sprintf("%s (HEAD)", st.Name),
+		Hint:  joinHints(st.Hint, "A HEAD request returns the status and headers of the GET request, without body"),
+		Spec:  st.Spec,
+		Specs: st.Specs,
+		Steps: []test.StepBuilder{
+			test.Step("GET").
+				Request(st.Request.Clone()).
+				Response(recordHeaders{get: get}),
+			test.Step("HEAD").
+				Request(st.Request.Clone().Method(http.MethodHead)).
+				Response(matchesGETHeaders{get: get, expect: headExpect(st.Response)}),
+		},
+	}
+}
+
+// getResponse is the response of the GET step of a HEAD scenario.
+type getResponse struct {
+	header http.Header
+}
+
+// recordHeaders keeps the headers of the GET response for the HEAD step.
+type recordHeaders struct {
+	get *getResponse
+}
+
+var _ test.ExpectValidator = recordHeaders{}
+
+func (r recordHeaders) Validate(t *testing.T, res *http.Response, localReport test.Reporter) {
+	r.get.header = res.Header.Clone()
+}
+
+func (r recordHeaders) Clone() test.ExpectValidator {
+	return r
+}
+
+// matchesGETHeaders validates the HEAD response with the expectations of the
+// test, and compares its representation headers and Content-Length with the
+// ones of the GET response.
+type matchesGETHeaders struct {
+	get    *getResponse
+	expect test.ExpectValidator
+}
+
+var _ test.ExpectValidator = matchesGETHeaders{}
+
+func (m matchesGETHeaders) Validate(t *testing.T, res *http.Response, localReport test.Reporter) {
+	t.Helper()
+	m.expect.Validate(t, res, localReport)
+
+	if m.get.header == nil {
+		localReport(t, "The GET request of the HEAD request failed")
+		return
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(m.get.header)) {
+		if !isHEADRepresentationHeader(key) {
+			continue
+		}
+		t.Run(fmt.Sprintf("Header %s matches GET", key), func(t *testing.T) {
+			expected := strings.Join(m.get.header.Values(key), ", ")
+			if actual := res.Header.Values(key); len(actual) == 0 {
+				localReport(t, "Header %s is missing, the GET response has '%s'", key, expected)
+			} else if strings.Join(actual, ", ") != expected {
+				localReport(t, "Header %s is '%s', the GET response has '%s'", key, strings.Join(actual, ", "), expected)
+			}
+		})
+	}
+
+	// A streamed GET response has no Content-Length to compare with.
+	expected, actual := m.get.header.Get("Content-Length"), res.Header.Get("Content-Length")
+	if res.StatusCode >= 200 && res.StatusCode < 300 && expected != "" && actual != "" {
+		t.Run("Header Content-Length matches GET", func(t *testing.T) {
+			if actual != expected {
+				localReport(t, "Header Content-Length is '%s', the GET response has '%s'", actual, expected)
+			}
+		})
+	}
+}
+
+func (m matchesGETHeaders) Clone() test.ExpectValidator {
+	return matchesGETHeaders{get: m.get, expect: m.expect.Clone()}
+}
+
+func headExpect(v test.ExpectValidator) test.ExpectValidator {
+	switch v := v.(type) {
+	case test.ExpectBuilder:
+		return headExpectBuilder(v)
+	case test.AllOfExpectBuilder:
+		var expects []test.ExpectValidator
+		for _, e := range v.Expect_ {
+			expects = append(expects, headExpect(e))
+		}
+		return test.AllOf(expects...)
+	case test.AnyOfExpectBuilder:
+		var expects []test.ExpectBuilder
+		for _, e := range v.Expect_ {
+			expects = append(expects, headExpectBuilder(e))
+		}
+		return test.AnyOf(expects...)
+	default:
+		panic(fmt.Sprintf("can only transform an ExpectBuilder, AllOfExpectBuilder or AnyOfExpectBuilder into a HEAD expectation, got %T", v))
+	}
+}
+
+func headExpectBuilder(e test.ExpectBuilder) test.ExpectBuilder {
+	headers := make([]test.HeaderBuilder, 0, len(e.Headers_))
+	for _, h := range e.Headers_ {
+		if !slices.Contains(headBodyDependentHeaders, http.CanonicalHeaderKey(h.Key_)) {
+			headers = append(headers, h)
+		}
+	}
+	e.Headers_ = headers
+	return e.BodyWithHint("A HEAD response has no body", "").Specs(slices.Concat(e.Specs_, []string{headSpec})...)
+}
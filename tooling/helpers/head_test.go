@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHEADMatchesGETHeaders(t *testing.T) {
+	response := func(status int, headers ...string) *http.Response {
+		res := &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}
+		for i := 0; i < len(headers); i += 2 {
+			res.Header.Add(headers[i], headers[i+1])
+		}
+		return res
+	}
+	get := response(http.StatusOK, "Content-Type", "text/plain", "Content-Length", "4", "Etag", `"cid"`, "X-Ipfs-Path", "/ipfs/cid", "Age", "10", "Date", "Sun, 18 Oct 2026 10:00:00 GMT")
+	redirect := response(http.StatusMovedPermanently, "Location", "/ipfs/cid/", "Content-Length", "54")
+
+	tests := []struct {
+		name     string
+		get      *http.Response
+		head     *http.Response
+		expected []string
+	}{
+		{"same headers", get, response(http.StatusOK, "Content-Type", "text/plain", "Content-Length", "4", "Etag", `"cid"`, "X-Ipfs-Path", "/ipfs/cid"), nil},
+		{"without Content-Length", get, response(http.StatusOK, "Content-Type", "text/plain", "Etag", `"cid"`, "X-Ipfs-Path", "/ipfs/cid"), nil},
+		{"other per-message headers", get, response(http.StatusOK, "Content-Type", "text/plain", "Etag", `"cid"`, "X-Ipfs-Path", "/ipfs/cid", "Age", "0", "Via", "1.1 cdn"), nil},
+		{"other Content-Length", get, response(http.StatusOK, "Content-Type", "text/plain", "Content-Length", "54", "Etag", `"cid"`, "X-Ipfs-Path", "/ipfs/cid"), []string{"Header Content-Length is '54', the GET response has '4'"}},
+		{"other Content-Length of a redirect", redirect, response(http.StatusMovedPermanently, "Location", "/ipfs/cid/", "Content-Length", "0"), nil},
+		{"other Etag", get, response(http.StatusOK, "Content-Type", "text/plain", "Etag", `"other"`, "X-Ipfs-Path", "/ipfs/cid"), []string{`Header Etag is '"other"', the GET response has '"cid"'`}},
+		{"missing X-Ipfs-Path", get, response(http.StatusOK, "Content-Type", "text/plain", "Etag", `"cid"`), []string{"Header X-Ipfs-Path is missing, the GET response has '/ipfs/cid'"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported []string
+			report := func(t *testing.T, msg any, rest ...any) {
+				reported = append(reported, fmt.Sprintf(msg.(string), rest...))
+			}
+
+			recorded := &getResponse{}
+			recordHeaders{get: recorded}.Validate(t, tt.get, report)
+			expect := headExpect(test.Expect().Status(tt.head.StatusCode))
+			matchesGETHeaders{get: recorded, expect: expect}.Validate(t, tt.head, report)
+			assert.Equal(t, tt.expected, reported)
+		})
+	}
+}
+
+func TestIsHEADCandidate(t *testing.T) {
+	expect := test.Expect().Status(http.StatusOK)
+	tests := []struct {
+		name     string
+		request  test.RequestBuilder
+		expected bool
+	}{
+		{"GET", test.Request().Path("/ipfs/cid"), true},
+		{"explicit GET", test.Request().Path("/ipfs/cid").Method(http.MethodGet), true},
+		{"POST", test.Request().Path("/ipfs/cid").Method(http.MethodPost), false},
+		{"Range", test.Request().Path("/ipfs/cid").Header("Range", "bytes=0-1"), false},
+		{"If-Range", test.Request().Path("/ipfs/cid").Header("If-Range", `"cid"`), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isHEADCandidate(test.SugarTest{Request: tt.request, Response: expect}))
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package helpers
+
+import "strings"
+
+// joinHints joins the non-empty hints of a test and of its transform, one per
+// line.
+func joinHints(hints ...string) string {
+	var lines []string
+	for _, hint := range hints {
+		if hint = strings.TrimSpace(hint); hint != "" {
+			lines = append(lines, hint)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,23 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinHints(t *testing.T) {
+	tests := []struct {
+		hints    []string
+		expected string
+	}{
+		{[]string{"", "transform"}, "transform"},
+		{[]string{"\n\t\t\ttest\n\t\t", "transform"}, "test\ntransform"},
+		{[]string{"test", ""}, "test"},
+		{[]string{"", " "}, ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, joinHints(tt.hints...))
+	}
+}
@@ -2,7 +2,10 @@ package helpers
 
 import (
 	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -11,52 +14,121 @@ import (
 	"github.com/ipfs/gateway-conformance/tooling/test"
 )
 
-// parseRange parses a ranges header in the format "bytes=from-to" and returns
-// x and y as uint64.
-func parseRange(t *testing.T, str string) (from, to uint64) {
-	if !strings.HasPrefix(str, "bytes=") {
-		t.Fatalf("byte range %s does not start with 'bytes='", str)
-	}
+// byteRange is a range-spec of a Range header resolved against the size of the
+// representation: first and last are the positions of the first and last
+// bytes, included.
+type byteRange struct {
+	first, last int64
+	// satisfiable is false when the range starts after the end of the
+	// representation, or is a suffix of length 0.
+	satisfiable bool
+}
 
-	str = strings.TrimPrefix(str, "bytes=")
-	ranges := strings.Split(str, ",")
-	if len(ranges) != 1 {
-		t.Fatalf("byte range %s must have one range", str)
-	}
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.first, r.last, size)
+}
 
-	rng := strings.Split(ranges[0], "-")
-	if len(rng) != 2 {
-		t.Fatalf("byte range %s is invalid", str)
+func (r byteRange) overlaps(o byteRange) bool {
+	return r.first <= o.last && o.first <= r.last
+}
+
+// coalesce merges the overlapping ranges, as a server may send them in one
+// part, at the position of the first one.
+func coalesce(ranges []byteRange) []byteRange {
+	var out []byteRange
+	for _, r := range ranges {
+		i := slices.IndexFunc(out, r.overlaps)
+		if i < 0 {
+			out = append(out, r)
+			continue
+		}
+		out[i].first = min(out[i].first, r.first)
+		out[i].last = max(out[i].last, r.last)
 	}
+	if len(out) < len(ranges) {
+		// A merged range may overlap another one now.
+		return coalesce(out)
+	}
+	return out
+}
 
-	var err error
-	from, err = strconv.ParseUint(rng[0], 10, 0)
-	if err != nil {
-		t.Fatalf("cannot parse range %s: %s", str, err.Error())
+// parseRanges parses a Range header in the format "bytes=range-spec, ...",
+// as in RFC 9110 section 14.1.2, where a range-spec is "from-to", "from-", or
+// the suffix "-length", and resolves the ranges against the size.
+func parseRanges(t testing.TB, str string, size int64) []byteRange {
+	specs, ok := strings.CutPrefix(str, "bytes=")
+	if !ok {
+		t.Fatalf("byte range %s does not start with 'bytes='", str)
 	}
 
-	to, err = strconv.ParseUint(rng[1], 10, 0)
-	if err != nil {
-		t.Fatalf("cannot parse range %s: %s", str, err.Error())
+	var ranges []byteRange
+	for spec := range strings.SplitSeq(specs, ",") {
+		fromStr, toStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+		if !ok || (fromStr == "" && toStr == "") {
+			t.Fatalf("byte range %s is invalid", str)
+		}
+
+		var r byteRange
+		if fromStr == "" {
+			// A suffix range, the last bytes of the representation.
+			length, err := strconv.ParseInt(toStr, 10, 64)
+			if err != nil {
+				t.Fatalf("cannot parse range %s: %s", str, err.Error())
+			}
+			r = byteRange{first: max(size-length, 0), last: size - 1, satisfiable: length > 0 && size > 0}
+		} else {
+			from, err := strconv.ParseInt(fromStr, 10, 64)
+			if err != nil {
+				t.Fatalf("cannot parse range %s: %s", str, err.Error())
+			}
+			to := size - 1
+			if toStr != "" {
+				if to, err = strconv.ParseInt(toStr, 10, 64); err != nil {
+					t.Fatalf("cannot parse range %s: %s", str, err.Error())
+				}
+				if to < from {
+					t.Fatalf("byte range %s is invalid: %d is before %d", str, to, from)
+				}
+				to = min(to, size-1)
+			}
+			r = byteRange{first: from, last: to, satisfiable: from < size}
+		}
+		ranges = append(ranges, r)
 	}
 
-	return from, to
+	return ranges
+}
+
+// parseRange parses a Range header with a single range-spec, see parseRanges.
+func parseRange(t testing.TB, str string, size int64) byteRange {
+	ranges := parseRanges(t, str, size)
+	if len(ranges) != 1 {
+		t.Fatalf("byte range %s must have one range", str)
+	}
+	return ranges[0]
 }
 
 // combineRanges combines the multiple request ranges into a single Range header.
 func combineRanges(t *testing.T, ranges []string) string {
-	var str strings.Builder
-	str.WriteString("bytes=")
-
-	for i, rng := range ranges {
-		from, to := parseRange(t, rng)
-		str.WriteString(fmt.Sprintf("%d-%d", from, to))
-		if i != len(ranges)-1 {
-			str.WriteString(",")
+	specs := make([]string, 0, len(ranges))
+	for _, rng := range ranges {
+		spec, ok := strings.CutPrefix(rng, "bytes=")
+		if !ok {
+			t.Fatalf("byte range %s does not start with 'bytes='", rng)
 		}
+		specs = append(specs, spec)
 	}
 
-	return str.String()
+	return "bytes=" + strings.Join(specs, ",")
+}
+
+// contentTypeHeaders returns the check of the Content-Type, none when
+// contentType is empty.
+func contentTypeHeaders(contentType string) []test.HeaderBuilder {
+	if contentType == "" {
+		return nil
+	}
+	return []test.HeaderBuilder{test.Header("Content-Type", contentType)}
 }
 
 // SingleRangeTestTransform takes a test where there is no "Range" header set in the request, or checks on the
@@ -71,27 +143,37 @@ func SingleRangeTestTransform(t *testing.T, baseTest test.SugarTest, byteRange s
 	modifiedResponse := baseTest.Response.Clone()
 
 	fullSize := int64(len(fullData))
-	start, end := parseRange(t, byteRange)
+	r := parseRange(t, byteRange, fullSize)
+	if !r.satisfiable {
+		t.Fatalf("byte range %s is not satisfiable, use UnsatisfiableRangeTestTransform", byteRange)
+	}
 
 	rangeTest := test.SugarTest{
 		Name:     baseTest.Name,
 		Hint:     baseTest.Hint,
+		Spec:     baseTest.Spec,
+		Specs:    baseTest.Specs,
 		Request:  modifiedRequest,
 		Requests: nil,
 		Response: test.AllOf(
 			modifiedResponse,
-			test.AnyOf(
-				test.Expect().Status(http.StatusPartialContent).Body(fullData[start:end+1]).Headers(
-					test.Header("Content-Range").Equals("bytes {{start}}-{{end}}/{{length}}", start, end, fullSize),
-				),
-				test.Expect().Status(http.StatusOK).Body(fullData),
-			),
+			rangeResponse(r, fullData),
 		),
 	}
 
 	return rangeTest
 }
 
+// rangeResponse expects the partial data of the range, or the full data.
+func rangeResponse(r byteRange, fullData []byte) test.ExpectValidator {
+	return test.AnyOf(
+		test.Expect().Status(http.StatusPartialContent).Body(fullData[r.first:r.last+1]).Headers(
+			test.Header("Content-Range").Equals(r.contentRange(int64(len(fullData)))),
+		),
+		test.Expect().Status(http.StatusOK).Body(fullData),
+	)
+}
+
 // MultiRangeTestTransform takes a test where there is no "Range" header set in the request, or checks on the
 // StatusCode, Body, or Content-Range or Content-Type headers and verifies whether a valid response is given for the
 // requested ranges.
@@ -99,55 +181,136 @@ func SingleRangeTestTransform(t *testing.T, baseTest test.SugarTest, byteRange s
 // If contentType is empty it is ignored.
 //
 // Note: HTTP Multi Range requests can be validly responded with one of the full data, the partial data from the first
-// range, or the partial data from all the requested ranges.
+// satisfiable range, or a multipart/byteranges body with the partial data of all the satisfiable ranges, in order.
+// Overlapping ranges may be coalesced into one part.
 func MultiRangeTestTransform(t *testing.T, baseTest test.SugarTest, byteRanges []string, fullData []byte, contentType string) test.SugarTest {
-	modifiedRequest := baseTest.Request.Clone().Header("Range", combineRanges(t, byteRanges))
+	header := combineRanges(t, byteRanges)
+	modifiedRequest := baseTest.Request.Clone().Header("Range", header)
 	if baseTest.Requests != nil {
 		t.Fatal("does not support multiple requests or responses")
 	}
 	modifiedResponse := baseTest.Response.Clone()
 
 	fullSize := int64(len(fullData))
-	type rng struct {
-		start, end uint64
+
+	var ranges []byteRange
+	for _, r := range parseRanges(t, header, fullSize) {
+		if r.satisfiable {
+			ranges = append(ranges, r)
+		}
+	}
+	if len(ranges) == 0 {
+		t.Fatalf("byte ranges %s are not satisfiable, use UnsatisfiableRangeTestTransform", header)
+	}
+
+	multipartResponse := func(parts []byteRange) test.ExpectBuilder {
+		c := check.IsMultipartByteranges().WithContentType(contentType)
+		for _, r := range parts {
+			c = c.WithPart(r.contentRange(fullSize), fullData[r.first:r.last+1])
+		}
+		return test.Expect().Status(http.StatusPartialContent).Body(c)
 	}
 
-	var multirangeBodyChecks []check.Check[string]
-	var ranges []rng
-	for _, r := range byteRanges {
-		start, end := parseRange(t, r)
-		ranges = append(ranges, rng{start: start, end: end})
-		multirangeBodyChecks = append(multirangeBodyChecks,
-			check.Contains("Content-Range: bytes {{start}}-{{end}}/{{length}}", ranges[0].start, ranges[0].end, fullSize),
-			check.Contains(string(fullData[start:end+1])),
-		)
+	options := []test.ExpectBuilder{
+		test.Expect().Status(http.StatusOK).Body(fullData).Headers(contentTypeHeaders(contentType)...),
+		test.Expect().Status(http.StatusPartialContent).Body(fullData[ranges[0].first : ranges[0].last+1]).Headers(
+			append(contentTypeHeaders(contentType), test.Header("Content-Range").Equals(ranges[0].contentRange(fullSize)))...,
+		),
+		multipartResponse(ranges),
+	}
+	if coalesced := coalesce(ranges); len(coalesced) < len(ranges) {
+		options = append(options, multipartResponse(coalesced))
 	}
 
 	rangeTest := test.SugarTest{
 		Name:     baseTest.Name,
 		Hint:     baseTest.Hint,
+		Spec:     baseTest.Spec,
+		Specs:    baseTest.Specs,
 		Request:  modifiedRequest,
 		Requests: nil,
 		Response: test.AllOf(
 			modifiedResponse,
-			test.AnyOf(
-				test.Expect().Status(http.StatusOK).Body(fullData).Header(test.Header("Content-Type", contentType)),
-				test.Expect().Status(http.StatusPartialContent).Body(fullData[ranges[0].start:ranges[0].end+1]).Headers(
-					test.Header("Content-Range").Equals("bytes {{start}}-{{end}}/{{length}}", ranges[0].start, ranges[0].end, fullSize),
-					test.Header("Content-Type", contentType),
-				),
-				test.Expect().Status(http.StatusPartialContent).Body(
-					check.And(
-						append([]check.Check[string]{check.Contains("Content-Type: {{contentType}}", contentType)}, multirangeBodyChecks...)...,
-					),
-				).Headers(test.Header("Content-Type").Contains("multipart/byteranges")),
-			),
+			test.AnyOf(options...),
 		),
 	}
 
 	return rangeTest
 }
 
+// UnsatisfiableRangeTestTransform takes a test where there is no "Range" header set in the request, or checks on
+// the StatusCode, Body, or Content-Range headers and verifies that a 416 Range Not Satisfiable response with the
+// Content-Range "bytes */{size}" is given for ranges that are all after the end of the data.
+func UnsatisfiableRangeTestTransform(t *testing.T, baseTest test.SugarTest, byteRange string, fullData []byte) test.SugarTest {
+	if baseTest.Requests != nil {
+		t.Fatal("does not support multiple requests or responses")
+	}
+
+	fullSize := int64(len(fullData))
+	for _, r := range parseRanges(t, byteRange, fullSize) {
+		if r.satisfiable {
+			t.Fatalf("byte range %s is satisfiable", byteRange)
+		}
+	}
+
+	return test.SugarTest{
+		Name:    baseTest.Name,
+		Hint:    baseTest.Hint,
+		Spec:    baseTest.Spec,
+		Specs:   baseTest.Specs,
+		Request: baseTest.Request.Clone().Header("Range", byteRange),
+		Response: test.AllOf(
+			baseTest.Response.Clone(),
+			test.Expect().Status(http.StatusRequestedRangeNotSatisfiable).Headers(
+				test.Header("Content-Range").Equals("bytes */{{size}}", fullSize),
+			),
+		),
+	}
+}
+
+// IfRangeTestTransform takes a test where there is no "Range" header set in the request, or checks on the
+// StatusCode, Body, or Content-Range headers and returns a scenario sending the range with the If-Range validators
+// of a first response: with its Etag and Last-Modified the range may be returned, with another Etag or an older
+// date the full data must be returned, as in RFC 9110 section 13.1.5. The steps depending on a header the gateway
+// did not send are skipped.
+func IfRangeTestTransform(t *testing.T, baseTest test.SugarTest, byteRange string, fullData []byte) test.SugarTest {
+	if baseTest.Requests != nil {
+		t.Fatal("does not support multiple requests or responses")
+	}
+
+	r := parseRange(t, byteRange, int64(len(fullData)))
+	if !r.satisfiable {
+		t.Fatalf("byte range %s is not satisfiable", byteRange)
+	}
+
+	ifRange := func(name string, expect test.ExpectValidator, value string, args ...any) test.StepBuilder {
+		return test.Step(name).
+			Request(baseTest.Request.Clone().Header("Range", byteRange).Header("If-Range", value, args...)).
+			Response(test.AllOf(baseTest.Response.Clone(), expect))
+	}
+	full := test.Expect().Status(http.StatusOK).Body(fullData)
+
+	return test.SugarTest{
+		Name:  baseTest.Name,
+		Hint:  baseTest.Hint,
+		Spec:  baseTest.Spec,
+		Specs: baseTest.Specs,
+		Steps: []test.StepBuilder{
+			test.Step("GET").
+				Request(baseTest.Request.Clone()).
+				Response(test.Expect().Status(http.StatusOK)).
+				Capture(
+					test.Capture("etag").FromHeader("Etag").Optional(),
+					test.Capture("lastModified").FromHeader("Last-Modified").Optional(),
+				),
+			ifRange("If-Range with the Etag", rangeResponse(r, fullData), "{{etag}}", test.Var("etag")),
+			ifRange("If-Range with the Last-Modified", rangeResponse(r, fullData), "{{lastModified}}", test.Var("lastModified")),
+			ifRange("If-Range with another Etag", full, `"gateway-conformance-other-etag"`),
+			ifRange("If-Range with an older date", full, "Sat, 01 Jan 2000 00:00:00 GMT"),
+		},
+	}
+}
+
 // IncludeRangeTests takes a test where there is no "Range" header set in the request, or checks on the
 // StatusCode, Body, or Content-Range headers and verifies whether a valid response is given for the requested ranges.
 // Will test the full request, a single range request for the first passed range as well as a multi-range request for
@@ -263,14 +426,61 @@ func onlyRangeTests(t *testing.T, baseTest test.SugarTest, byteRanges []string,
 	return test.SugarTests{singleRange, multiRange}
 }
 
+// RangeGrammarTests takes a test where there is no "Range" header set in the request, or checks on the
+// StatusCode, Body, or Content-Range headers and returns the range requests covering the grammar of RFC 9110
+// section 14: suffix and open-ended ranges, overlapping, out-of-order and partially satisfiable multi-ranges,
+// unsatisfiable ranges expecting a 416, and If-Range with the Etag or a date.
+//
+// If contentType is empty it is ignored.
+//
+// Data smaller than 10 bytes will produce a panic to avoid undefined behavior.
+func RangeGrammarTests(t *testing.T, baseTest test.SugarTest, fullData []byte, contentType string) test.SugarTests {
+	size := len(fullData)
+	if size < 10 {
+		panic("transformation not defined for data smaller than 10 bytes")
+	}
+
+	named := func(name string) test.SugarTest {
+		st := baseTest
+		st.Name = fmt.Sprintf("%s - %s", baseTest.Name, name)
+		return st
+	}
+
+	return test.SugarTests{
+		SingleRangeTestTransform(t, named("suffix range"), fmt.Sprintf("bytes=-%d", size/4+1), fullData),
+		SingleRangeTestTransform(t, named("open-ended range"), fmt.Sprintf("bytes=%d-", size/2), fullData),
+		SingleRangeTestTransform(t, named("range past the end"), fmt.Sprintf("bytes=%d-%d", size/2, size*2), fullData),
+		MultiRangeTestTransform(t, named("overlapping ranges"), []string{"bytes=1-5", "bytes=3-8"}, fullData, contentType),
+		MultiRangeTestTransform(t, named("out-of-order ranges"), []string{"bytes=-3", fmt.Sprintf("bytes=%d-%d", size/2, size/2+1), "bytes=0-1"}, fullData, contentType),
+		MultiRangeTestTransform(t, named("partially satisfiable ranges"), []string{fmt.Sprintf("bytes=%d-", size+5), "bytes=0-3"}, fullData, contentType),
+		UnsatisfiableRangeTestTransform(t, named("unsatisfiable range"), fmt.Sprintf("bytes=%d-", size), fullData),
+		UnsatisfiableRangeTestTransform(t, named("unsatisfiable ranges"), fmt.Sprintf("bytes=%d-%d,%d-", size, size+10, size+100), fullData),
+		IfRangeTestTransform(t, named("If-Range"), "bytes=0-3", fullData),
+	}
+}
+
+// makeRandomByteRanges returns two non-overlapping ranges, out of order: one in
+// the second half of the data, then one in the first half. The ranges are
+// pseudo-random, derived from the data, so a test requests the same ranges on
+// every run.
 func makeRandomByteRanges(fullData []byte) []string {
 	dataLen := len(fullData)
 	if dataLen < 10 {
 		panic("transformation not defined for data smaller than 10 bytes")
 	}
 
+	h := fnv.New64a()
+	h.Write(fullData)
+	rnd := rand.New(rand.NewPCG(h.Sum64(), uint64(dataLen)))
+
+	half := dataLen / 2
+	secondFrom := half + rnd.IntN(dataLen-half-1)
+	secondTo := secondFrom + rnd.IntN(dataLen-secondFrom)
+	firstFrom := rnd.IntN(half - 1)
+	firstTo := firstFrom + rnd.IntN(half-firstFrom)
+
 	return []string{
-		"bytes=7-9",
-		"bytes=1-3",
+		fmt.Sprintf("bytes=%d-%d", secondFrom, secondTo),
+		fmt.Sprintf("bytes=%d-%d", firstFrom, firstTo),
 	}
 }
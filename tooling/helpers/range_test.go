@@ -0,0 +1,153 @@
+package helpers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fatalRecorder stops the parsing at the first Fatalf, and keeps its message.
+type fatalRecorder struct {
+	testing.TB
+	msg string
+}
+
+type fatalStop struct{}
+
+func (f *fatalRecorder) Fatalf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+	panic(fatalStop{})
+}
+
+func parseRangesOrFatal(str string, size int64) (ranges []byteRange, fatal string) {
+	f := &fatalRecorder{}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(fatalStop); !ok {
+				panic(r)
+			}
+			fatal = f.msg
+		}
+	}()
+	return parseRanges(f, str, size), ""
+}
+
+func TestParseRanges(t *testing.T) {
+	tests := []struct {
+		header   string
+		size     int64
+		expected []byteRange
+		fatal    string
+	}{
+		{"bytes=0-0", 10, []byteRange{{0, 0, true}}, ""},
+		{"bytes=2-5", 10, []byteRange{{2, 5, true}}, ""},
+		{"bytes=7-", 10, []byteRange{{7, 9, true}}, ""},
+		{"bytes=-3", 10, []byteRange{{7, 9, true}}, ""},
+		// A suffix longer than the representation is all of it.
+		{"bytes=-30", 10, []byteRange{{0, 9, true}}, ""},
+		{"bytes=-0", 10, []byteRange{{10, 9, false}}, ""},
+		// The last position is capped at the end of the representation.
+		{"bytes=5-100", 10, []byteRange{{5, 9, true}}, ""},
+		{"bytes=10-20", 10, []byteRange{{10, 9, false}}, ""},
+		{"bytes=0-1, 4-5,-2", 10, []byteRange{{0, 1, true}, {4, 5, true}, {8, 9, true}}, ""},
+		{"bytes=5-4", 10, nil, "byte range bytes=5-4 is invalid: 4 is before 5"},
+		{"bytes=-", 10, nil, "byte range bytes=- is invalid"},
+		{"bytes=a-1", 10, nil, `cannot parse range bytes=a-1: strconv.ParseInt: parsing "a": invalid syntax`},
+		{"items=0-1", 10, nil, "byte range items=0-1 does not start with 'bytes='"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			ranges, fatal := parseRangesOrFatal(tt.header, tt.size)
+			assert.Equal(t, tt.fatal, fatal)
+			assert.Equal(t, tt.expected, ranges)
+		})
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranges   []byteRange
+		expected []byteRange
+	}{
+		{"disjoint", []byteRange{{5, 6, true}, {0, 1, true}}, []byteRange{{5, 6, true}, {0, 1, true}}},
+		{"adjacent ranges are not merged", []byteRange{{0, 1, true}, {2, 3, true}}, []byteRange{{0, 1, true}, {2, 3, true}}},
+		{"overlapping", []byteRange{{0, 4, true}, {3, 6, true}}, []byteRange{{0, 6, true}}},
+		{"contained", []byteRange{{2, 3, true}, {0, 9, true}}, []byteRange{{0, 9, true}}},
+		// {0, 2} and {6, 8} are disjoint until {2, 6} merges them.
+		{"chain", []byteRange{{0, 2, true}, {6, 8, true}, {2, 6, true}}, []byteRange{{0, 8, true}}},
+		{"chain merged after the first pass", []byteRange{{0, 1, true}, {4, 5, true}, {1, 2, true}, {3, 4, true}, {2, 3, true}}, []byteRange{{0, 5, true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, coalesce(tt.ranges))
+		})
+	}
+}
+
+func TestMakeRandomByteRanges(t *testing.T) {
+	data := []byte("hello gateway conformance, some bytes to request")
+	ranges := makeRandomByteRanges(data)
+	assert.Equal(t, ranges, makeRandomByteRanges(data), "the ranges of the same data differ")
+
+	require.Len(t, ranges, 2)
+	size := int64(len(data))
+	second := parseRange(t, ranges[0], size)
+	first := parseRange(t, ranges[1], size)
+	assert.GreaterOrEqual(t, second.first, size/2)
+	assert.Less(t, first.last, size/2)
+	assert.False(t, first.overlaps(second))
+
+	for _, r := range []byteRange{first, second} {
+		assert.True(t, r.satisfiable)
+		assert.LessOrEqual(t, r.first, r.last)
+		assert.Less(t, r.last, size)
+	}
+
+	assert.Panics(t, func() { makeRandomByteRanges([]byte("too small")) })
+}
+
+func TestIfRangeTestTransform(t *testing.T) {
+	data := []byte("0123456789")
+	base := test.SugarTest{
+		Name:     "GET a file",
+		Request:  test.Request().Path("/ipfs/cid"),
+		Response: test.Expect().Header(test.Header("Cache-Control").Exists()),
+	}
+
+	st := IfRangeTestTransform(t, base, "bytes=2-4", data)
+	assert.Equal(t, "GET a file", st.Name)
+
+	var names []string
+	for _, step := range st.Steps {
+		names = append(names, step.Name_)
+		assert.Equal(t, "/ipfs/cid", step.Request_.Path_)
+	}
+	assert.Equal(t, []string{
+		"GET",
+		"If-Range with the Etag",
+		"If-Range with the Last-Modified",
+		"If-Range with another Etag",
+		"If-Range with an older date",
+	}, names)
+
+	get := st.Steps[0]
+	assert.Empty(t, get.Request_.Headers_["Range"])
+	require.Len(t, get.Capture_, 2)
+	assert.Equal(t, "etag", get.Capture_[0].Name_)
+	assert.Equal(t, "lastModified", get.Capture_[1].Name_)
+
+	for _, step := range st.Steps[1:] {
+		assert.Equal(t, "bytes=2-4", step.Request_.Headers_["Range"], step.Name_)
+	}
+	assert.Equal(t, `"gateway-conformance-other-etag"`, st.Steps[3].Request_.Headers_["If-Range"])
+	assert.Equal(t, "Sat, 01 Jan 2000 00:00:00 GMT", st.Steps[4].Request_.Headers_["If-Range"])
+
+	// The base request is not modified.
+	assert.Empty(t, base.Request.Headers_)
+}
@@ -0,0 +1,221 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/car"
+	"github.com/ipfs/gateway-conformance/tooling/check"
+	"github.com/ipfs/gateway-conformance/tooling/dnslink"
+	"github.com/ipfs/gateway-conformance/tooling/specs"
+	"github.com/ipfs/gateway-conformance/tooling/test"
+	"github.com/ipfs/go-cid"
+)
+
+const subdomainSpec = "https://specs.ipfs.tech/http-gateways/subdomain-gateway/"
+
+// SubdomainGatewayTransforms adds, after every path gateway test, the same
+// test sent to the subdomain gateway: /ipfs/{cid}/path is requested as /path
+// with the Host {cid}.ipfs.{host}, where {cid} is the DNS-safe CIDv1 and
+// {host} the host of SubdomainGatewayURL, and /ipns/{name}/path as /path with
+// the Host {name}.ipns.{host}. The expected Location headers are checked
+// against the content path the subdomain stands for.
+//
+// The tests are only added when the subdomain gateway specs are enabled. The
+// tests already sending a Host header, or using a proxy, are not transformed.
+func SubdomainGatewayTransforms(t *testing.T, sts test.SugarTests) test.SugarTests {
+	t.Helper()
+
+	host := test.SubdomainGatewayURL().Host
+
+	var out test.SugarTests
+	for _, st := range sts {
+		out = append(out, st)
+		if sub, ok := subdomainTest(st, host); ok {
+			out = append(out, sub)
+		}
+	}
+	return out
+}
+
+func subdomainTest(st test.SugarTest, host string) (test.SugarTest, bool) {
+	sub := test.SugarTest{
+		Name:      fmt.Sprintf("%s (subdomain gateway)", st.Name),
+		Hint:      joinHints(st.Hint, "A subdomain gateway returns the same response as the path gateway for the same content path"),
+		Specs:     slices.Concat(st.AllSpecs(), []string{subdomainSpec}),
+		Responses: st.Responses,
+	}
+
+	// The request of a test is unset when it has several requests or steps.
+	if len(st.Requests) == 0 && len(st.Steps) == 0 {
+		r, loc, ok := subdomainRequest(st.Request, host)
+		if !ok {
+			return test.SugarTest{}, false
+		}
+		sub.Request = r
+		sub.Response = subdomainExpect(st.Response, loc)
+	}
+	for _, req := range st.Requests {
+		r, _, ok := subdomainRequest(req, host)
+		if !ok {
+			return test.SugarTest{}, false
+		}
+		sub.Requests = append(sub.Requests, r)
+	}
+	for _, step := range st.Steps {
+		r, loc, ok := subdomainRequest(step.Request_, host)
+		if !ok {
+			return test.SugarTest{}, false
+		}
+		step.Request_ = r
+		step.Response_ = subdomainExpect(step.Response_, loc)
+		sub.Steps = append(sub.Steps, step)
+	}
+	return sub, true
+}
+
+// subdomainRequest returns the subdomain gateway request equivalent to a path
+// gateway request, and how its Location headers map back to content paths.
+func subdomainRequest(r test.RequestBuilder, host string) (test.RequestBuilder, subdomainLocation, bool) {
+	if r.Proxy_ != "" || r.UseProxyTunnel_ {
+		return r, subdomainLocation{}, false
+	}
+	for key := range r.Headers_ {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			return r, subdomainLocation{}, false
+		}
+	}
+
+	path, query, _ := strings.Cut(r.Path_, "?")
+	parts := strings.SplitN(path, "/", 4)
+	if len(parts) < 3 || parts[0] != "" {
+		return r, subdomainLocation{}, false
+	}
+	namespace, root := parts[1], parts[2]
+
+	var label string
+	switch namespace {
+	case "ipfs":
+		if !specs.SubdomainGatewayIPFS.IsEnabled() {
+			return r, subdomainLocation{}, false
+		}
+		c, err := cid.Decode(root)
+		if err != nil {
+			return r, subdomainLocation{}, false
+		}
+		label = car.DNSSafeCidV1(c)
+	case "ipns":
+		if !specs.SubdomainGatewayIPNS.IsEnabled() {
+			return r, subdomainLocation{}, false
+		}
+		var ok bool
+		if label, ok = ipnsLabel(root); !ok {
+			return r, subdomainLocation{}, false
+		}
+	default:
+		return r, subdomainLocation{}, false
+	}
+
+	rest := "/"
+	if len(parts) == 4 {
+		rest += parts[3]
+	}
+	if query != "" {
+		rest += "?" + query
+	}
+
+	subHost := fmt.Sprintf("%s.%s.%s", label, namespace, host)
+	loc := subdomainLocation{
+		prefix: fmt.Sprintf("/%s/%s", namespace, root),
+		host:   subHost,
+	}
+	return r.Clone().Path(rest).Header("Host", subHost), loc, true
+}
+
+// ipnsLabel returns the DNS label of an IPNS name: the base36 CIDv1 of a key,
+// or the inlined DNSLink name. A base58 peer ID has no DNS label, the path
+// gateway redirects it to the CIDv1 instead.
+func ipnsLabel(name string) (string, bool) {
+	if c, err := cid.Decode(name); err == nil {
+		if c.Type() != cid.Libp2pKey {
+			return "", false
+		}
+		return car.DNSSafeCidV1(c), true
+	}
+	if strings.Contains(name, ".") {
+		return dnslink.InlineDNS(name), true
+	}
+	return "", false
+}
+
+func subdomainExpect(v test.ExpectValidator, loc subdomainLocation) test.ExpectValidator {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case test.ExpectBuilder:
+		return subdomainExpectBuilder(v, loc)
+	case test.AllOfExpectBuilder:
+		var expects []test.ExpectValidator
+		for _, e := range v.Expect_ {
+			expects = append(expects, subdomainExpect(e, loc))
+		}
+		return test.AllOf(expects...)
+	case test.AnyOfExpectBuilder:
+		var expects []test.ExpectBuilder
+		for _, e := range v.Expect_ {
+			expects = append(expects, subdomainExpectBuilder(e, loc))
+		}
+		return test.AnyOf(expects...)
+	default:
+		panic(fmt.Sprintf("can only transform an ExpectBuilder, AllOfExpectBuilder or AnyOfExpectBuilder into a subdomain gateway expectation, got %T", v))
+	}
+}
+
+func subdomainExpectBuilder(e test.ExpectBuilder, loc subdomainLocation) test.ExpectBuilder {
+	headers := make([]test.HeaderBuilder, 0, len(e.Headers_))
+	for _, h := range e.Headers_ {
+		if http.CanonicalHeaderKey(h.Key_) == "Location" && h.Check_ != nil {
+			h = h.Clone()
+			h.Check_ = loc.with(h.Check_)
+		}
+		headers = append(headers, h)
+	}
+	e.Headers_ = headers
+	return e
+}
+
+// subdomainLocation maps the Location headers of a subdomain gateway response
+// to the content paths expected from the path gateway: a path on the
+// subdomain, e.g. /dir/, stands for /ipfs/{cid}/dir/.
+type subdomainLocation struct {
+	prefix string
+	host   string
+	check  check.Check[[]string]
+}
+
+func (l subdomainLocation) with(c check.Check[[]string]) subdomainLocation {
+	l.check = c
+	return l
+}
+
+func (l subdomainLocation) Check(values []string) check.CheckOutput {
+	paths := make([]string, 0, len(values))
+	for _, v := range values {
+		paths = append(paths, l.contentPath(v))
+	}
+	return l.check.Check(paths)
+}
+
+func (l subdomainLocation) contentPath(location string) string {
+	if u, err := url.Parse(location); err == nil && u.Host == l.host {
+		location = u.RequestURI()
+	}
+	if !strings.HasPrefix(location, "/") || strings.HasPrefix(location, "/ipfs/") || strings.HasPrefix(location, "/ipns/") {
+		return location
+	}
+	return l.prefix + location
+}
@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cidV0 = "QmbWqxBEKC3P8tqsKc98xmWNzrzDtRLMiMPL8wBuTGsMnR"
+	cidV1 = "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"
+	ipnsK = "k51qzi5uqu5dhjghbwdvbo6mi40htrq6e2z4pwgp15pgv3ho1azvidttzh8yy2"
+)
+
+func TestSubdomainRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  test.RequestBuilder
+		ok       bool
+		path     string
+		host     string
+		location string
+	}{
+		{"CIDv1", test.Request().Path("/ipfs/" + cidV1), true, "/", cidV1 + ".ipfs.example.com", "/ipfs/" + cidV1},
+		{"CIDv0 as CIDv1", test.Request().Path("/ipfs/" + cidV0 + "/dir/file.txt"), true, "/dir/file.txt", cidV1 + ".ipfs.example.com", "/ipfs/" + cidV0},
+		{"query", test.Request().Path("/ipfs/" + cidV1 + "/dir/?format=raw"), true, "/dir/?format=raw", cidV1 + ".ipfs.example.com", "/ipfs/" + cidV1},
+		{"IPNS key", test.Request().Path("/ipns/" + ipnsK + "/file"), true, "/file", ipnsK + ".ipns.example.com", "/ipns/" + ipnsK},
+		{"DNSLink name inlined", test.Request().Path("/ipns/en.wikipedia-on-ipfs.org/wiki/"), true, "/wiki/", "en-wikipedia--on--ipfs-org.ipns.example.com", "/ipns/en.wikipedia-on-ipfs.org"},
+		{"base58 peer ID", test.Request().Path("/ipns/12D3KooWLQzUv2FHWGVPXTXSZpdHs7oHbXub2G5WC8Tx4NQhyd2d"), false, "", "", ""},
+		{"not a CID", test.Request().Path("/ipfs/not-a-cid"), false, "", "", ""},
+		{"not a content path", test.Request().Path("/api/v0/version"), false, "", "", ""},
+		{"Host header", test.Request().Path("/ipfs/"+cidV1).Header("Host", "localhost"), false, "", "", ""},
+		{"proxy", test.Request().Path("/ipfs/" + cidV1).Proxy("http://127.0.0.1:8080"), false, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, loc, ok := subdomainRequest(tt.request, "example.com")
+			require.Equal(t, tt.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.path, r.Path_)
+			assert.Equal(t, tt.host, r.Headers_["Host"])
+			assert.Equal(t, tt.location, loc.prefix)
+			assert.Equal(t, tt.host, loc.host)
+		})
+	}
+}
+
+func TestSubdomainLocation(t *testing.T) {
+	loc := subdomainLocation{prefix: "/ipfs/" + cidV1, host: cidV1 + ".ipfs.example.com"}
+
+	tests := []struct {
+		location string
+		expected string
+	}{
+		{"/dir/", "/ipfs/" + cidV1 + "/dir/"},
+		{"/dir/?query=1", "/ipfs/" + cidV1 + "/dir/?query=1"},
+		{"http://" + cidV1 + ".ipfs.example.com/dir/", "/ipfs/" + cidV1 + "/dir/"},
+		{"/ipfs/" + cidV0, "/ipfs/" + cidV0},
+		{"/ipns/example.com/", "/ipns/example.com/"},
+		{"https://other.example.com/dir/", "https://other.example.com/dir/"},
+		{"dir/", "dir/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			assert.Equal(t, tt.expected, loc.contentPath(tt.location))
+		})
+	}
+}
+
+func TestSubdomainTest(t *testing.T) {
+	st := test.SugarTest{
+		Name: "GET a directory without trailing slash redirects",
+		Request: test.Request().
+			Path("/ipfs/{{cid}}/dir", cidV0),
+		Response: test.Expect().
+			Status(301).
+			Headers(test.Header("Location").Equals("/ipfs/{{cid}}/dir/", cidV0)),
+	}
+
+	sub, ok := subdomainTest(st, "example.com")
+	require.True(t, ok)
+	assert.Equal(t, "GET a directory without trailing slash redirects (subdomain gateway)", sub.Name)
+	assert.Equal(t, "/dir", sub.Request.Path_)
+	assert.Equal(t, cidV1+".ipfs.example.com", sub.Request.Headers_["Host"])
+	assert.Contains(t, sub.Specs, subdomainSpec)
+
+	expect, ok := sub.Response.(test.ExpectBuilder)
+	require.True(t, ok)
+	require.Len(t, expect.Headers_, 1)
+	location := expect.Headers_[0].Check_
+	assert.True(t, location.Check([]string{"/dir/"}).Success)
+	assert.True(t, location.Check([]string{"http://" + cidV1 + ".ipfs.example.com/dir/"}).Success)
+	assert.False(t, location.Check([]string{"/other/"}).Success)
+
+	// The path gateway test is not modified.
+	assert.Equal(t, "/ipfs/"+cidV0+"/dir", st.Request.Path_)
+	assert.Empty(t, st.Request.Headers_)
+}
@@ -24,6 +24,11 @@ func LogTestGroup(t *testing.T, name string) {
 	}{
 		Group: name,
 	})
+
+	recordGroup(t, name)
+	if !groupSelected(name) {
+		t.Skipf("skipping tests, group %q is not selected", name)
+	}
 }
 
 func LogVersion(t *testing.T) {
@@ -47,6 +52,8 @@ func LogSpecs(t *testing.T, specs ...string) {
 		return
 	}
 
+	recordSpecs(t, specs)
+
 	LogMetadata(t, struct {
 		Specs []string `json:"specs"`
 	}{
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Baseline lists the full names of the tests known to fail, e.g.
+// "TestTar/GET_TAR_with_format=tar/Status_code". An entry also covers the
+// subtests of the test it names.
+type Baseline []string
+
+// LoadBaseline reads a baseline file: a JSON array of test full names.
+func LoadBaseline(path string) (Baseline, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(b, &baseline); err != nil {
+		return nil, fmt.Errorf("error parsing baseline %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+// NewBaseline returns the failed leaf tests of the report. Tests that did not
+// complete (e.g. when the run timed out) are considered failed.
+func NewBaseline(r *Report) Baseline {
+	baseline := Baseline{}
+	for _, test := range r.Leaves() {
+		if test.Outcome == Fail || test.Outcome == Unknown {
+			baseline = append(baseline, test.Name)
+		}
+	}
+	slices.Sort(baseline)
+	return baseline
+}
+
+// Write writes the baseline as an indented JSON array, one test per line.
+func (b Baseline) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// covers returns the baseline entries covering the test.
+func (b Baseline) covers(name string) []string {
+	var entries []string
+	for _, entry := range b {
+		if name == entry || strings.HasPrefix(name, entry+"/") {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// BaselineResult compares the failures of a run with a baseline.
+type BaselineResult struct {
+	// ExpectedFailures are the failed tests covered by the baseline.
+	ExpectedFailures []string
+	// NewFailures are the failed tests not covered by the baseline.
+	NewFailures []string
+	// UnexpectedlyPassing are the baseline entries whose tests ran and all
+	// passed (or were skipped): they can be removed from the baseline.
+	UnexpectedlyPassing []string
+}
+
+// CompareBaseline compares the failed leaf tests of the report with a
+// baseline. Baseline entries that match no test of the run (e.g. filtered
+// out with -run) are ignored.
+func (r *Report) CompareBaseline(b Baseline) BaselineResult {
+	var result BaselineResult
+	ran := map[string]bool{}
+	failed := map[string]bool{}
+
+	for _, name := range NewBaseline(r) {
+		entries := b.covers(name)
+		if len(entries) == 0 {
+			result.NewFailures = append(result.NewFailures, name)
+			continue
+		}
+		result.ExpectedFailures = append(result.ExpectedFailures, name)
+		for _, entry := range entries {
+			failed[entry] = true
+		}
+	}
+
+	for _, test := range r.Leaves() {
+		for _, entry := range b.covers(test.Name) {
+			ran[entry] = true
+		}
+	}
+
+	for _, entry := range b {
+		if ran[entry] && !failed[entry] && !slices.Contains(result.UnexpectedlyPassing, entry) {
+			result.UnexpectedlyPassing = append(result.UnexpectedlyPassing, entry)
+		}
+	}
+
+	return result
+}
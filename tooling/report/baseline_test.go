@@ -0,0 +1,44 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBaseline(t *testing.T) {
+	baseline := NewBaseline(parse(t))
+	assert.Equal(t, Baseline{"TestTar/GET_TAR/Status_code"}, baseline)
+
+	var buf bytes.Buffer
+	require.NoError(t, baseline.Write(&buf))
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	loaded, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Equal(t, baseline, loaded)
+}
+
+func TestCompareBaseline(t *testing.T) {
+	r := parse(t)
+
+	result := r.CompareBaseline(Baseline{
+		"TestTar/GET_TAR",                     // covers the failing subtest
+		"TestTar/GET_TAR/Header_Content-Type", // passes
+		"TestTar/Skipped",                     // skipped, passes
+		"TestRemoved",                         // did not run
+	})
+	assert.Equal(t, []string{"TestTar/GET_TAR/Status_code"}, result.ExpectedFailures)
+	assert.Empty(t, result.NewFailures)
+	assert.Equal(t, []string{"TestTar/GET_TAR/Header_Content-Type", "TestTar/Skipped"}, result.UnexpectedlyPassing)
+
+	result = r.CompareBaseline(Baseline{"TestTa"})
+	assert.Empty(t, result.ExpectedFailures)
+	assert.Equal(t, []string{"TestTar/GET_TAR/Status_code"}, result.NewFailures)
+	assert.Empty(t, result.UnexpectedlyPassing)
+}
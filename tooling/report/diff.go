@@ -0,0 +1,178 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Kinds of change between two reports.
+const (
+	NewlyFailing = "newly_failing"
+	NewlyPassing = "newly_passing"
+	Added        = "added"
+	Removed      = "removed"
+)
+
+// Change is a leaf test whose outcome differs between two reports.
+type Change struct {
+	Name   string   `json:"name"`
+	Kind   string   `json:"kind"`
+	Group  string   `json:"group,omitempty"`
+	Specs  []string `json:"specs,omitempty"`
+	Before string   `json:"before,omitempty"` // "" when added
+	After  string   `json:"after,omitempty"`  // "" when removed
+}
+
+// DiffSummary counts the changes per kind.
+type DiffSummary struct {
+	NewlyFailing int `json:"newly_failing"`
+	NewlyPassing int `json:"newly_passing"`
+	Added        int `json:"added"`
+	Removed      int `json:"removed"`
+}
+
+// DiffResult lists the changes between two reports, sorted by group, specs
+// and test name.
+type DiffResult struct {
+	Before  map[string]any `json:"before"` // run metadata of the first report
+	After   map[string]any `json:"after"`  // run metadata of the second report
+	Summary DiffSummary    `json:"summary"`
+	Changes []Change       `json:"changes"`
+}
+
+func failing(outcome string) bool {
+	return outcome == Fail || outcome == Unknown
+}
+
+// Diff aligns the leaf tests of two reports by full name and returns the
+// tests that started failing or passing, and the tests only present in one of
+// them. A skipped test is neither passing nor failing.
+func Diff(before, after *Report) *DiffResult {
+	d := &DiffResult{
+		Before:  before.RunMeta(),
+		After:   after.RunMeta(),
+		Changes: []Change{},
+	}
+
+	add := func(r *Report, test *Test, kind, outcomeBefore, outcomeAfter string) {
+		d.Changes = append(d.Changes, Change{
+			Name:   test.Name,
+			Kind:   kind,
+			Group:  r.Group(test),
+			Specs:  r.Specs(test),
+			Before: outcomeBefore,
+			After:  outcomeAfter,
+		})
+	}
+
+	for _, test := range after.Leaves() {
+		if test.Name == "TestMetadata" {
+			continue
+		}
+		prev := before.Test(test.Name)
+		switch {
+		case prev == nil:
+			add(after, test, Added, "", test.Outcome)
+			d.Summary.Added++
+		case failing(test.Outcome) && !failing(prev.Outcome):
+			add(after, test, NewlyFailing, prev.Outcome, test.Outcome)
+			d.Summary.NewlyFailing++
+		case test.Outcome == Pass && failing(prev.Outcome):
+			add(after, test, NewlyPassing, prev.Outcome, test.Outcome)
+			d.Summary.NewlyPassing++
+		}
+	}
+
+	for _, test := range before.Leaves() {
+		if test.Name == "TestMetadata" || after.Test(test.Name) != nil {
+			continue
+		}
+		add(before, test, Removed, test.Outcome, "")
+		d.Summary.Removed++
+	}
+
+	sort.SliceStable(d.Changes, func(i, j int) bool {
+		a, b := d.Changes[i], d.Changes[j]
+		if a.Group != b.Group {
+			return diffGroup(a) < diffGroup(b)
+		}
+		if specs := strings.Join(a.Specs, " "); specs != strings.Join(b.Specs, " ") {
+			return specs < strings.Join(b.Specs, " ")
+		}
+		return a.Name < b.Name
+	})
+
+	return d
+}
+
+// diffGroup returns the group of a change, tests without a group sort last.
+func diffGroup(c Change) string {
+	if c.Group == "" {
+		return "\uffff"
+	}
+	return c.Group
+}
+
+// WriteDiffJSON writes the diff as indented JSON, for bots and scripts.
+func WriteDiffJSON(w io.Writer, d *DiffResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteDiffText writes a human readable diff: the changes grouped by test
+// group and specs.
+func WriteDiffText(w io.Writer, d *DiffResult) error {
+	var b strings.Builder
+
+	version := func(meta map[string]any) string {
+		v, _ := meta["version"].(string)
+		if v == "" {
+			v = "unknown"
+		}
+		if gw, _ := meta["gateway_url"].(string); gw != "" {
+			v += " (" + gw + ")"
+		}
+		return v
+	}
+	fmt.Fprintf(&b, "Before: %s\nAfter:  %s\n\n", version(d.Before), version(d.After))
+	fmt.Fprintf(&b, "%d newly failing, %d newly passing, %d added, %d removed\n",
+		d.Summary.NewlyFailing, d.Summary.NewlyPassing, d.Summary.Added, d.Summary.Removed)
+
+	var group, specs string
+	for i, c := range d.Changes {
+		if i == 0 || c.Group != group {
+			group = c.Group
+			specs = "\x00"
+			name := group
+			if name == "" {
+				name = "Other"
+			}
+			fmt.Fprintf(&b, "\n## %s\n", name)
+		}
+		if s := strings.Join(c.Specs, ", "); s != specs {
+			specs = s
+			if s == "" {
+				s = "no specs"
+			}
+			fmt.Fprintf(&b, "\n  %s\n", s)
+		}
+
+		var outcome string
+		switch c.Kind {
+		case Added:
+			outcome = c.After
+		case Removed:
+			outcome = c.Before
+		default:
+			outcome = c.Before + " -> " + c.After
+		}
+		fmt.Fprintf(&b, "    %-14s %s (%s)\n", strings.ReplaceAll(c.Kind, "_", " "), c.Name, outcome)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
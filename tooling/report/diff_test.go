@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before := parse(t)
+
+	// Status_code now passes, Header_Content-Type fails, Skipped is gone and
+	// TestNew is added.
+	after, err := Parse(strings.NewReader(`{"Action":"run","Test":"TestMetadata"}
+{"Action":"output","Test":"TestMetadata","Output":"    metadata.go:21: --- META: {\"version\":\"v1.1.0\"}\n"}
+{"Action":"pass","Test":"TestMetadata"}
+{"Action":"run","Test":"TestTar"}
+{"Action":"output","Test":"TestTar","Output":"    path_gateway_tar.go:14: --- META: {\"group\":\"Tar\"}\n"}
+{"Action":"run","Test":"TestTar/GET_TAR"}
+{"Action":"output","Test":"TestTar/GET_TAR","Output":"    test.go:82: --- META: {\"specs\":[\"https://specs.ipfs.tech/http-gateways/path-gateway/\"]}\n"}
+{"Action":"run","Test":"TestTar/GET_TAR/Status_code"}
+{"Action":"pass","Test":"TestTar/GET_TAR/Status_code"}
+{"Action":"run","Test":"TestTar/GET_TAR/Header_Content-Type"}
+{"Action":"fail","Test":"TestTar/GET_TAR/Header_Content-Type"}
+{"Action":"fail","Test":"TestTar/GET_TAR"}
+{"Action":"fail","Test":"TestTar"}
+{"Action":"run","Test":"TestNew"}
+{"Action":"pass","Test":"TestNew"}
+`))
+	require.NoError(t, err)
+
+	d := Diff(before, after)
+	assert.Equal(t, DiffSummary{NewlyFailing: 1, NewlyPassing: 1, Added: 1, Removed: 1}, d.Summary)
+
+	specs := []string{"https://specs.ipfs.tech/http-gateways/path-gateway/"}
+	assert.Equal(t, []Change{
+		{Name: "TestTar/Skipped", Kind: Removed, Group: "Tar", Before: Skip},
+		{Name: "TestTar/GET_TAR/Header_Content-Type", Kind: NewlyFailing, Group: "Tar", Specs: specs, Before: Pass, After: Fail},
+		{Name: "TestTar/GET_TAR/Status_code", Kind: NewlyPassing, Group: "Tar", Specs: specs, Before: Fail, After: Pass},
+		{Name: "TestNew", Kind: Added, After: Pass},
+	}, d.Changes)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDiffText(&buf, d))
+	assert.Equal(t, `Before: v1.0.0
+After:  v1.1.0
+
+1 newly failing, 1 newly passing, 1 added, 1 removed
+
+## Tar
+
+  no specs
+    removed        TestTar/Skipped (skip)
+
+  https://specs.ipfs.tech/http-gateways/path-gateway/
+    newly failing  TestTar/GET_TAR/Header_Content-Type (pass -> fail)
+    newly passing  TestTar/GET_TAR/Status_code (fail -> pass)
+
+## Other
+
+  no specs
+    added          TestNew (pass)
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteDiffJSON(&buf, d))
+	var decoded DiffResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, d.Summary, decoded.Summary)
+	assert.Equal(t, d.Changes, decoded.Changes)
+}
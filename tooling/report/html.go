@@ -0,0 +1,128 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"sort"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"emoji": outcomeEmoji,
+	"meta":  metaString,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Gateway Conformance</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+details { margin: 0.2em 0 0.2em 1.5em; }
+summary { cursor: pointer; }
+pre { background: #f6f8fa; padding: 0.5em; overflow-x: auto; }
+.fail > summary { color: #b00; font-weight: bold; }
+.skip > summary { color: #888; }
+</style>
+</head>
+<body>
+<h1>Gateway Conformance {{ emoji .Report.Outcome }}</h1>
+{{- with .Meta }}
+<table>
+{{- range . }}
+<tr><th>{{ .Key }}</th><td>{{ .Value }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+<h2>Summary</h2>
+<table>
+<tr><th>Group</th><th>Total</th><th>Passed</th><th>Failed</th><th>Skipped</th></tr>
+{{- range .Report.Groups }}
+<tr><td>{{ .Group }}</td><td>{{ .Total }}</td><td>{{ .Passed }}</td><td>{{ .Failed }}</td><td>{{ .Skipped }}</td></tr>
+{{- end }}
+{{- with .Summary }}
+<tr><th>Total</th><th>{{ .Total }}</th><th>{{ .Passed }}</th><th>{{ .Failed }}</th><th>{{ .Skipped }}</th></tr>
+{{- end }}
+</table>
+<h2>Tests</h2>
+{{- template "tests" .Tests }}
+</body>
+</html>
+{{ define "tests" }}
+{{- range . }}
+<details class="{{ .Test.Outcome }}"{{ if eq .Test.Outcome "fail" }} open{{ end }}>
+<summary>{{ emoji .Test.Outcome }} {{ .Name }}</summary>
+{{- with .Specs }}
+<ul>
+{{- range . }}
+<li><a href="{{ . }}">{{ . }}</a></li>
+{{- end }}
+</ul>
+{{- end }}
+{{- if .Children }}
+{{- template "tests" .Children }}
+{{- else if .Test.Output }}
+<pre>{{ .Test.Output }}</pre>
+{{- end }}
+</details>
+{{- end }}
+{{- end }}
+`))
+
+type htmlMeta struct {
+	Key, Value string
+}
+
+type htmlTest struct {
+	Name     string
+	Test     *Test
+	Specs    []string
+	Children []htmlTest
+}
+
+// WriteHTML writes the report as a self-contained HTML page, with the tests
+// displayed as a tree and failures expanded.
+func WriteHTML(w io.Writer, r *Report) error {
+	meta := r.RunMeta()
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var metas []htmlMeta
+	for _, k := range keys {
+		metas = append(metas, htmlMeta{Key: k, Value: metaString(meta[k])})
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Report  *Report
+		Meta    []htmlMeta
+		Summary Summary
+		Tests   []htmlTest
+	}{
+		Report:  r,
+		Meta:    metas,
+		Summary: r.Summary(),
+		Tests:   r.htmlTests(""),
+	})
+}
+
+func (r *Report) htmlTests(parent string) []htmlTest {
+	var tests []htmlTest
+	for _, test := range r.Children(parent) {
+		var specs []string
+		if spec, ok := test.Meta["specs"].([]any); ok {
+			for _, s := range spec {
+				specs = append(specs, metaString(s))
+			}
+		}
+		tests = append(tests, htmlTest{
+			Name:     test.Path[len(test.Path)-1],
+			Test:     test,
+			Specs:    specs,
+			Children: r.htmlTests(test.Name),
+		})
+	}
+	return tests
+}
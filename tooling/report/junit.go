@@ -0,0 +1,193 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The JUnit XML format as understood by GitLab, Jenkins and most CI
+// dashboards: https://github.com/testmoapp/junitxml
+
+type junitTestSuites struct {
+	XMLName   xml.Name         `xml:"testsuites"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	Suites    []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Cases      []junitTestCase  `xml:"testcase"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitMessage    `xml:"failure,omitempty"`
+	Skipped    *junitMessage    `xml:"skipped,omitempty"`
+	SystemOut  *junitOutput     `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Content string `xml:",cdata"`
+}
+
+// WriteJUnit writes the report as JUnit XML. Every top-level test is a test
+// suite, and its leaf subtests are the test cases.
+func WriteJUnit(w io.Writer, r *Report) error {
+	summary := r.Summary()
+	suites := junitTestSuites{
+		Name:     "Gateway Tests",
+		Tests:    summary.Total,
+		Failures: summary.Failed,
+		Skipped:  summary.Skipped,
+		Time:     seconds(r.Elapsed),
+	}
+	if !r.Time.IsZero() {
+		suites.Timestamp = r.Time.Format(time.RFC3339)
+	}
+
+	for _, top := range r.Children("") {
+		suite := junitTestSuite{
+			Name:       top.Name,
+			Time:       seconds(top.Elapsed),
+			Properties: properties(top.Meta),
+		}
+
+		for _, test := range r.leaves(top) {
+			suite.Tests++
+			tc := junitTestCase{
+				Name:      test.Name,
+				ClassName: top.Name,
+				Time:      seconds(test.Elapsed),
+			}
+			var props []junitProperty
+			for _, spec := range r.Specs(test) {
+				props = append(props, junitProperty{Name: "spec", Value: spec})
+			}
+			if test.Flaky {
+				props = append(props, junitProperty{Name: "flaky", Value: "true"})
+			}
+			if len(props) > 0 {
+				tc.Properties = &junitProperties{Properties: props}
+			}
+
+			output := xmlText(test.Output)
+			switch test.Outcome {
+			case Fail:
+				suite.Failures++
+				tc.Failure = &junitMessage{Message: "Failed", Content: output}
+			case Skip:
+				suite.Skipped++
+				tc.Skipped = &junitMessage{Message: "Skipped", Content: output}
+			default:
+				tc.SystemOut = &junitOutput{Content: output}
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// leaves returns the leaf tests under (and including) the given test.
+func (r *Report) leaves(test *Test) []*Test {
+	children := r.Children(test.Name)
+	if len(children) == 0 {
+		return []*Test{test}
+	}
+
+	var leaves []*Test
+	for _, child := range children {
+		leaves = append(leaves, r.leaves(child)...)
+	}
+	return leaves
+}
+
+func properties(meta map[string]any) *junitProperties {
+	if len(meta) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	props := &junitProperties{}
+	for _, k := range keys {
+		props.Properties = append(props.Properties, junitProperty{Name: k, Value: xmlText(metaString(meta[k]))})
+	}
+	return props
+}
+
+// xmlText drops the characters XML 1.0 cannot represent, e.g. terminal escape
+// sequences in the test output.
+func xmlText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r != 0xFFFE && r != 0xFFFF) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func metaString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []any:
+		values := make([]string, len(v))
+		for i, value := range v {
+			values[i] = metaString(value)
+		}
+		return strings.Join(values, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func seconds(elapsed float64) string {
+	return fmt.Sprintf("%.3f", elapsed)
+}
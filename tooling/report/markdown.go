@@ -0,0 +1,100 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown writes a summary of the report as Markdown, suitable for
+// a GitHub job summary or a merge request comment: the metadata of the run,
+// the outcomes per group and the details of every failure.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+
+	summary := r.Summary()
+	fmt.Fprintf(&b, "# Gateway Conformance %s\n\n", outcomeEmoji(r.Outcome))
+
+	meta := r.RunMeta()
+	if len(meta) > 0 {
+		keys := make([]string, 0, len(meta))
+		for k := range meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("| Key | Value |\n|---|---|\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "| %s | %s |\n", k, markdownCell(metaString(meta[k])))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Group | Total | Passed | Failed | Skipped |\n|---|---|---|---|---|\n")
+	for _, g := range r.Groups() {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d |\n", markdownCell(g.Group), g.Total, g.Passed, g.Failed, g.Skipped)
+	}
+	fmt.Fprintf(&b, "| **Total** | **%d** | **%d** | **%d** | **%d** |\n\n", summary.Total, summary.Passed, summary.Failed, summary.Skipped)
+
+	failures := r.Failures()
+	if len(failures) > 0 {
+		b.WriteString("## Failures\n\n")
+		for _, test := range failures {
+			fmt.Fprintf(&b, "<details>\n<summary>%s %s</summary>\n\n", outcomeEmoji(test.Outcome), html.EscapeString(test.Name))
+			if specs := r.Specs(test); len(specs) > 0 {
+				b.WriteString("Specs:\n")
+				for _, spec := range specs {
+					fmt.Fprintf(&b, "- %s\n", spec)
+				}
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s\n%s\n%s\n\n</details>\n\n", fence(test.Output), strings.TrimRight(test.Output, "\n"), fence(test.Output))
+		}
+	}
+
+	if flaky := r.Flaky(); len(flaky) > 0 {
+		b.WriteString("## Flaky\n\nThese tests passed after their requests were retried:\n\n")
+		for _, test := range flaky {
+			fmt.Fprintf(&b, "- %s\n", markdownCell(test.Name))
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func outcomeEmoji(outcome string) string {
+	switch outcome {
+	case Pass:
+		return "🟢"
+	case Fail:
+		return "🔴"
+	case Skip:
+		return "⚪"
+	default:
+		return "🟡"
+	}
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// fence returns a code fence longer than any backtick run in s.
+func fence(s string) string {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
@@ -0,0 +1,345 @@
+// Package report turns the test2json output of a conformance run, including
+// the `--- META:` lines logged with tooling.LogMetadata, into human and CI
+// friendly reports (JUnit XML, HTML and Markdown).
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Test outcomes.
+const (
+	Pass    = "pass"
+	Fail    = "fail"
+	Skip    = "skip"
+	Unknown = "unknown"
+)
+
+// Test is the result of a single test or subtest.
+type Test struct {
+	// Name is the full name of the test, e.g. "TestTar/GET_TAR_with_format=tar".
+	Name string `json:"-"`
+	// Path is the name split on "/" with underscores replaced by spaces.
+	Path    []string       `json:"path"`
+	Output  string         `json:"output"`
+	Outcome string         `json:"outcome"`
+	Time    time.Time      `json:"time,omitzero"`
+	Elapsed float64        `json:"elapsed,omitempty"`
+	Meta    map[string]any `json:"meta,omitempty"`
+	// Flaky is true when the test passed after its requests were retried.
+	Flaky bool `json:"flaky,omitempty"`
+}
+
+// Parent returns the full name of the parent test, or "" for a top-level test.
+func (t *Test) Parent() string {
+	i := strings.LastIndex(t.Name, "/")
+	if i < 0 {
+		return ""
+	}
+	return t.Name[:i]
+}
+
+// Report is the result of a test run.
+type Report struct {
+	// Tests lists every test and subtest, in the order they started.
+	Tests []*Test
+	// Outcome is the outcome of the whole run.
+	Outcome string
+	// Time is the time the run started.
+	Time time.Time
+	// Elapsed is the duration of the run, in seconds.
+	Elapsed float64
+
+	byName   map[string]*Test
+	children map[string][]*Test
+}
+
+type event struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+var metaRegexp = regexp.MustCompile(`--- META: (.*)`)
+
+// Parse reads a test2json stream, as written by the test command with
+// --json-output.
+func Parse(r io.Reader) (*Report, error) {
+	report := &Report{
+		Outcome:  Unknown,
+		byName:   map[string]*Test{},
+		children: map[string][]*Test{},
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var ev event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return nil, fmt.Errorf("failed to parse line %q: %w", line, err)
+		}
+
+		if ev.Test == "" {
+			report.addSuiteEvent(ev)
+			continue
+		}
+
+		test := report.test(ev)
+		switch ev.Action {
+		case "output":
+			test.Output += ev.Output
+			if m := metaRegexp.FindStringSubmatch(ev.Output); m != nil {
+				var meta map[string]any
+				if err := json.Unmarshal([]byte(m[1]), &meta); err != nil {
+					return nil, fmt.Errorf("failed to parse metadata of %s: %w", ev.Test, err)
+				}
+				test.addMeta(meta)
+			}
+		case "pass", "fail", "skip":
+			test.Outcome = ev.Action
+			test.Time = ev.Time
+			test.Elapsed = ev.Elapsed
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, test := range report.Tests {
+		test.Flaky = test.Outcome == Pass && report.Meta(test, "retries") != nil
+	}
+
+	return report, nil
+}
+
+func (r *Report) addSuiteEvent(ev event) {
+	switch ev.Action {
+	case "start":
+		r.Time = ev.Time
+	case "pass", "suite_pass":
+		r.Outcome = Pass
+		r.Elapsed = ev.Elapsed
+	case "fail", "suite_fail":
+		r.Outcome = Fail
+		r.Elapsed = ev.Elapsed
+	case "skip":
+		r.Outcome = Skip
+		r.Elapsed = ev.Elapsed
+	}
+}
+
+func (r *Report) test(ev event) *Test {
+	if test, ok := r.byName[ev.Test]; ok {
+		return test
+	}
+
+	test := &Test{
+		Name:    ev.Test,
+		Path:    strings.Split(strings.ReplaceAll(ev.Test, "_", " "), "/"),
+		Outcome: Unknown,
+	}
+	r.Tests = append(r.Tests, test)
+	r.byName[test.Name] = test
+	r.children[test.Parent()] = append(r.children[test.Parent()], test)
+	return test
+}
+
+func (t *Test) addMeta(meta map[string]any) {
+	if t.Meta == nil {
+		t.Meta = map[string]any{}
+	}
+	for k, v := range meta {
+		// The same test may log its specs more than once.
+		if prev, ok := t.Meta[k].([]any); ok {
+			if next, ok := v.([]any); ok {
+				v = append(prev, next...)
+			}
+		}
+		t.Meta[k] = v
+	}
+}
+
+// MarshalJSON encodes the report as an object mapping the full name of every
+// test to its result.
+func (r *Report) MarshalJSON() ([]byte, error) {
+	tests := make(map[string]*Test, len(r.Tests))
+	for _, test := range r.Tests {
+		tests[test.Name] = test
+	}
+	return json.Marshal(tests)
+}
+
+// WriteJSON writes the report as indented JSON, see MarshalJSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Test returns the test with the given full name, or nil.
+func (r *Report) Test(name string) *Test {
+	return r.byName[name]
+}
+
+// Children returns the direct subtests of the test with the given full name.
+// Use "" to list top-level tests.
+func (r *Report) Children(name string) []*Test {
+	return r.children[name]
+}
+
+// Leaves returns the tests without subtests, in the order they started.
+func (r *Report) Leaves() []*Test {
+	var leaves []*Test
+	for _, test := range r.Tests {
+		if len(r.children[test.Name]) == 0 {
+			leaves = append(leaves, test)
+		}
+	}
+	return leaves
+}
+
+// Meta returns the metadata value logged by the test or its closest ancestor,
+// e.g. the group of a subtest is logged by its top-level test.
+func (r *Report) Meta(test *Test, key string) any {
+	for t := test; t != nil; t = r.byName[t.Parent()] {
+		if v, ok := t.Meta[key]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// Group returns the group of a test (see tests/metadata.go), or "".
+func (r *Report) Group(test *Test) string {
+	group, _ := r.Meta(test, "group").(string)
+	return group
+}
+
+// Specs returns the spec URLs covered by a test and its ancestors.
+func (r *Report) Specs(test *Test) []string {
+	var specs []string
+	for t := test; t != nil; t = r.byName[t.Parent()] {
+		values, _ := t.Meta["specs"].([]any)
+		for _, v := range values {
+			if s, ok := v.(string); ok && !slices.Contains(specs, s) {
+				specs = append(specs, s)
+			}
+		}
+	}
+	return specs
+}
+
+// RunMeta returns the metadata of the run (version, job_url, gateway_url,
+// ...) logged by TestMetadata.
+func (r *Report) RunMeta() map[string]any {
+	if test := r.byName["TestMetadata"]; test != nil && test.Meta != nil {
+		return test.Meta
+	}
+	return map[string]any{}
+}
+
+// Summary counts the outcomes of the leaf tests. Flaky tests are also
+// counted as passed.
+type Summary struct {
+	Total, Passed, Failed, Skipped, Unknown, Flaky int
+}
+
+func (s *Summary) add(test *Test) {
+	s.Total++
+	if test.Flaky {
+		s.Flaky++
+	}
+	switch test.Outcome {
+	case Pass:
+		s.Passed++
+	case Fail:
+		s.Failed++
+	case Skip:
+		s.Skipped++
+	default:
+		s.Unknown++
+	}
+}
+
+// Summary counts the outcomes of the leaf tests of the report.
+func (r *Report) Summary() Summary {
+	var s Summary
+	for _, test := range r.Leaves() {
+		s.add(test)
+	}
+	return s
+}
+
+// SummaryOf counts the outcomes of the leaf tests under (and including) the
+// given test.
+func (r *Report) SummaryOf(test *Test) Summary {
+	var s Summary
+	for _, leaf := range r.leaves(test) {
+		s.add(leaf)
+	}
+	return s
+}
+
+// GroupSummary counts the outcomes of the leaf tests of a group.
+type GroupSummary struct {
+	Group string
+	Summary
+}
+
+// Groups counts the outcomes of the leaf tests per group, in the order the
+// groups first appear. Tests without a group are counted under "Other".
+func (r *Report) Groups() []GroupSummary {
+	var groups []GroupSummary
+	index := map[string]int{}
+	for _, test := range r.Leaves() {
+		group := r.Group(test)
+		if group == "" {
+			group = "Other"
+		}
+		i, ok := index[group]
+		if !ok {
+			i = len(groups)
+			index[group] = i
+			groups = append(groups, GroupSummary{Group: group})
+		}
+		groups[i].add(test)
+	}
+	return groups
+}
+
+// Failures returns the failed leaf tests.
+func (r *Report) Failures() []*Test {
+	var failures []*Test
+	for _, test := range r.Leaves() {
+		if test.Outcome == Fail {
+			failures = append(failures, test)
+		}
+	}
+	return failures
+}
+
+// Flaky returns the leaf tests that passed after their requests were retried.
+func (r *Report) Flaky() []*Test {
+	var flaky []*Test
+	for _, test := range r.Leaves() {
+		if test.Flaky {
+			flaky = append(flaky, test)
+		}
+	}
+	return flaky
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const events = `{"Time":"2026-01-01T00:00:00Z","Action":"start","Package":"Gateway Tests"}
+{"Time":"2026-01-01T00:00:00Z","Action":"run","Package":"Gateway Tests","Test":"TestMetadata"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestMetadata","Output":"    metadata.go:21: --- META: {\"version\":\"v1.0.0\"}\n"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestMetadata","Output":"    metadata.go:22: --- META: {\"job_url\":\"https://ci.example.com/42\"}\n"}
+{"Time":"2026-01-01T00:00:00Z","Action":"pass","Package":"Gateway Tests","Test":"TestMetadata","Elapsed":0}
+{"Time":"2026-01-01T00:00:00Z","Action":"run","Package":"Gateway Tests","Test":"TestTar"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestTar","Output":"    path_gateway_tar.go:14: --- META: {\"group\":\"Tar\"}\n"}
+{"Time":"2026-01-01T00:00:00Z","Action":"run","Package":"Gateway Tests","Test":"TestTar/GET_TAR"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestTar/GET_TAR","Output":"    test.go:82: --- META: {\"specs\":[\"https://specs.ipfs.tech/http-gateways/path-gateway/\"]}\n"}
+{"Time":"2026-01-01T00:00:00Z","Action":"run","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Status_code"}
+{"Time":"2026-01-01T00:00:00Z","Action":"output","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Status_code","Output":"    run.go:58: Status code was not 200 <html> ` + "```" + `\n"}
+{"Time":"2026-01-01T00:00:01Z","Action":"fail","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Status_code","Elapsed":0.5}
+{"Time":"2026-01-01T00:00:01Z","Action":"run","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Header_Content-Type"}
+{"Time":"2026-01-01T00:00:01Z","Action":"pass","Package":"Gateway Tests","Test":"TestTar/GET_TAR/Header_Content-Type","Elapsed":0}
+{"Time":"2026-01-01T00:00:01Z","Action":"fail","Package":"Gateway Tests","Test":"TestTar/GET_TAR","Elapsed":0.5}
+{"Time":"2026-01-01T00:00:01Z","Action":"run","Package":"Gateway Tests","Test":"TestTar/Skipped"}
+{"Time":"2026-01-01T00:00:01Z","Action":"skip","Package":"Gateway Tests","Test":"TestTar/Skipped","Elapsed":0}
+{"Time":"2026-01-01T00:00:01Z","Action":"fail","Package":"Gateway Tests","Test":"TestTar","Elapsed":0.6}
+{"Time":"2026-01-01T00:00:01Z","Action":"suite_fail","Package":"Gateway Tests","Elapsed":1.2}
+`
+
+func parse(t *testing.T) *Report {
+	r, err := Parse(strings.NewReader(events))
+	require.NoError(t, err)
+	return r
+}
+
+func TestParse(t *testing.T) {
+	r := parse(t)
+
+	assert.Equal(t, Fail, r.Outcome)
+	assert.Equal(t, 1.2, r.Elapsed)
+	assert.Len(t, r.Tests, 6)
+	assert.Equal(t, map[string]any{"version": "v1.0.0", "job_url": "https://ci.example.com/42"}, r.RunMeta())
+
+	test := r.Test("TestTar/GET_TAR/Status_code")
+	require.NotNil(t, test)
+	assert.Equal(t, []string{"TestTar", "GET TAR", "Status code"}, test.Path)
+	assert.Equal(t, Fail, test.Outcome)
+	assert.Equal(t, 0.5, test.Elapsed)
+	assert.Equal(t, "Tar", r.Group(test))
+	assert.Equal(t, []string{"https://specs.ipfs.tech/http-gateways/path-gateway/"}, r.Specs(test))
+
+	assert.Equal(t, Summary{Total: 4, Passed: 2, Failed: 1, Skipped: 1}, r.Summary())
+	assert.Equal(t, []GroupSummary{
+		{Group: "Other", Summary: Summary{Total: 1, Passed: 1}},
+		{Group: "Tar", Summary: Summary{Total: 3, Passed: 1, Failed: 1, Skipped: 1}},
+	}, r.Groups())
+	assert.Equal(t, []*Test{test}, r.Failures())
+}
+
+func TestParseInvalidLine(t *testing.T) {
+	_, err := Parse(strings.NewReader("not json\n"))
+	assert.Error(t, err)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, parse(t)))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+
+	assert.Equal(t, 4, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	assert.Equal(t, 1, suites.Skipped)
+	require.Len(t, suites.Suites, 2)
+
+	tar := suites.Suites[1]
+	assert.Equal(t, "TestTar", tar.Name)
+	assert.Equal(t, &junitProperties{[]junitProperty{{Name: "group", Value: "Tar"}}}, tar.Properties)
+	require.Len(t, tar.Cases, 3)
+	assert.Equal(t, "TestTar/GET_TAR/Status_code", tar.Cases[0].Name)
+	assert.Equal(t, "TestTar", tar.Cases[0].ClassName)
+	require.NotNil(t, tar.Cases[0].Failure)
+	assert.Contains(t, tar.Cases[0].Failure.Content, "Status code was not 200 <html>")
+	assert.Nil(t, tar.Cases[1].Failure)
+	assert.NotNil(t, tar.Cases[2].Skipped)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteMarkdown(&buf, parse(t)))
+	md := buf.String()
+
+	assert.Contains(t, md, "| job_url | https://ci.example.com/42 |")
+	assert.Contains(t, md, "| Tar | 3 | 1 | 1 | 1 |")
+	assert.Contains(t, md, "<summary>🔴 TestTar/GET_TAR/Status_code</summary>")
+	assert.Contains(t, md, "- https://specs.ipfs.tech/http-gateways/path-gateway/")
+	// the output contains a code fence, the details must use a longer one
+	assert.Contains(t, md, "````\n    run.go:58")
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, parse(t)))
+	page := buf.String()
+
+	assert.Contains(t, page, "<th>version</th><td>v1.0.0</td>")
+	assert.Contains(t, page, `<details class="fail" open>`)
+	assert.Contains(t, page, "Status code was not 200 &lt;html&gt;")
+	assert.Contains(t, page, `<a href="https://specs.ipfs.tech/http-gateways/path-gateway/">`)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, parse(t)))
+
+	var tests map[string]Test
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &tests))
+
+	assert.Len(t, tests, 6)
+	test := tests["TestTar/GET_TAR/Status_code"]
+	assert.Equal(t, []string{"TestTar", "GET TAR", "Status code"}, test.Path)
+	assert.Equal(t, Fail, test.Outcome)
+	assert.Equal(t, map[string]any{"group": "Tar"}, tests["TestTar"].Meta)
+}
+
+func TestParseFlaky(t *testing.T) {
+	r, err := Parse(strings.NewReader(`{"Action":"run","Test":"TestA"}
+{"Action":"run","Test":"TestA/GET"}
+{"Action":"output","Test":"TestA/GET","Output":"    retry.go:75: --- META: {\"retries\":1}\n"}
+{"Action":"run","Test":"TestA/GET/Status_code"}
+{"Action":"pass","Test":"TestA/GET/Status_code"}
+{"Action":"pass","Test":"TestA/GET"}
+{"Action":"run","Test":"TestA/HEAD"}
+{"Action":"pass","Test":"TestA/HEAD"}
+{"Action":"pass","Test":"TestA"}
+`))
+	require.NoError(t, err)
+
+	assert.True(t, r.Test("TestA/GET").Flaky)
+	assert.False(t, r.Test("TestA").Flaky)
+	assert.Equal(t, []*Test{r.Test("TestA/GET/Status_code")}, r.Flaky())
+	assert.Equal(t, Summary{Total: 2, Passed: 2, Flaky: 1}, r.Summary())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMarkdown(&buf, r))
+	assert.Contains(t, buf.String(), "## Flaky\n\nThese tests passed after their requests were retried:\n\n- TestA/GET/Status_code\n")
+}
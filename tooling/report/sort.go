@@ -0,0 +1,86 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type eventNode struct {
+	// pre holds the events of the test until its first subtest starts, post
+	// the events after.
+	pre, post [][]byte
+	children  []*eventNode
+}
+
+// SortEvents copies a test2json stream, reordering the events so that the
+// events of every test are contiguous and nested: the events of a test before
+// its first subtest starts, the events of its subtests in the order they
+// started, then the remaining events of the test. Parallel tests interleave
+// their events, sorting them makes the stream of a parallel run deterministic.
+func SortEvents(w io.Writer, r io.Reader) error {
+	root := &eventNode{}
+	nodes := map[string]*eventNode{"": root}
+
+	var node func(name string) *eventNode
+	node = func(name string) *eventNode {
+		if n, ok := nodes[name]; ok {
+			return n
+		}
+		n := &eventNode{}
+		nodes[name] = n
+
+		parent := ""
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			parent = name[:i]
+		}
+		p := node(parent)
+		p.children = append(p.children, n)
+		return n
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var ev event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return fmt.Errorf("failed to parse line %q: %w", line, err)
+		}
+
+		n := node(ev.Test)
+		line = append([]byte(nil), line...)
+		if len(n.children) == 0 {
+			n.pre = append(n.pre, line)
+		} else {
+			n.post = append(n.post, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	var write func(n *eventNode)
+	write = func(n *eventNode) {
+		for _, line := range n.pre {
+			bw.Write(line)
+			bw.WriteByte('\n')
+		}
+		for _, child := range n.children {
+			write(child)
+		}
+		for _, line := range n.post {
+			bw.Write(line)
+			bw.WriteByte('\n')
+		}
+	}
+	write(root)
+	return bw.Flush()
+}
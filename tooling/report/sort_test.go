@@ -0,0 +1,58 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortEvents(t *testing.T) {
+	// TestA/x and TestA/y ran in parallel.
+	in := `{"Action":"start"}
+{"Action":"run","Test":"TestA"}
+{"Action":"output","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"run","Test":"TestA/x"}
+{"Action":"pause","Test":"TestA/x"}
+{"Action":"run","Test":"TestA/y"}
+{"Action":"pause","Test":"TestA/y"}
+{"Action":"cont","Test":"TestA/y"}
+{"Action":"cont","Test":"TestA/x"}
+{"Action":"output","Test":"TestA/y","Output":"y\n"}
+{"Action":"output","Test":"TestA/x","Output":"x\n"}
+{"Action":"pass","Test":"TestA/x"}
+{"Action":"pass","Test":"TestA/y"}
+{"Action":"pass","Test":"TestA"}
+{"Action":"run","Test":"TestB"}
+{"Action":"pass","Test":"TestB"}
+{"Action":"suite_pass"}
+`
+
+	var buf bytes.Buffer
+	require.NoError(t, SortEvents(&buf, strings.NewReader(in)))
+	assert.Equal(t, `{"Action":"start"}
+{"Action":"run","Test":"TestA"}
+{"Action":"output","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"run","Test":"TestA/x"}
+{"Action":"pause","Test":"TestA/x"}
+{"Action":"cont","Test":"TestA/x"}
+{"Action":"output","Test":"TestA/x","Output":"x\n"}
+{"Action":"pass","Test":"TestA/x"}
+{"Action":"run","Test":"TestA/y"}
+{"Action":"pause","Test":"TestA/y"}
+{"Action":"cont","Test":"TestA/y"}
+{"Action":"output","Test":"TestA/y","Output":"y\n"}
+{"Action":"pass","Test":"TestA/y"}
+{"Action":"pass","Test":"TestA"}
+{"Action":"run","Test":"TestB"}
+{"Action":"pass","Test":"TestB"}
+{"Action":"suite_pass"}
+`, buf.String())
+
+	// Sorting a sorted stream is a no-op.
+	var again bytes.Buffer
+	require.NoError(t, SortEvents(&again, bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, buf.String(), again.String())
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ipfs/boxo/blockservice"
+	"github.com/ipfs/boxo/blockstore"
+	"github.com/ipfs/boxo/gateway"
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/boxo/namesys"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+
+	"github.com/ipfs/gateway-conformance/tooling/dnslink"
+	"github.com/ipfs/gateway-conformance/tooling/fixtures"
+)
+
+// DefaultSubdomainHosts mirrors the public gateways configured in
+// kubo-config.example.sh.
+var DefaultSubdomainHosts = []string{"example.com", "localhost"}
+
+// NewHandler returns an http.Handler implementing a reference IPFS gateway
+// backed exclusively by the given fixtures: every CAR is loaded into an
+// in-memory blockstore, IPNS records are served from an in-memory value store
+// and DNSLink names are resolved from the dnslink.yml configuration files.
+//
+// Each host in subdomainHosts is configured as a subdomain gateway.
+func NewHandler(fxs *fixtures.Fixtures, subdomainHosts ...string) (http.Handler, error) {
+	bs, err := loadBlockstore(fxs.CarFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, err := loadValueStore(fxs.IPNSRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	dns, err := loadDNSLinks(fxs.ConfigFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := namesys.NewNameSystem(vs, namesys.WithDNSResolver(dns))
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := gateway.NewBlocksBackend(
+		blockservice.New(bs, nil),
+		gateway.WithValueStore(vs),
+		gateway.WithNameSystem(ns),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	publicGateways := map[string]*gateway.PublicGateway{}
+	for _, host := range subdomainHosts {
+		publicGateways[host] = &gateway.PublicGateway{
+			Paths:                 []string{"/ipfs", "/ipns"},
+			UseSubdomains:         true,
+			InlineDNSLink:         true,
+			DeserializedResponses: true,
+		}
+	}
+
+	conf := gateway.Config{
+		DeserializedResponses: true,
+		PublicGateways:        publicGateways,
+	}
+
+	handler := gateway.NewHandler(conf, backend)
+	handler = gateway.NewHeaders(nil).ApplyCors().Wrap(handler)
+	return gateway.NewHostnameHandler(conf, backend, handler), nil
+}
+
+// loadBlockstore imports every block of the given CAR files into a single
+// in-memory blockstore.
+func loadBlockstore(carFiles []string) (blockstore.Blockstore, error) {
+	// Identity CIDs (e.g. in IPNS record values) are inlined, never stored.
+	bs := blockstore.NewIdStore(blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore())))
+
+	for _, file := range carFiles {
+		err := func() error {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			br, err := carv2.NewBlockReader(f)
+			if err != nil {
+				return err
+			}
+
+			for {
+				blk, err := br.Next()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := bs.Put(context.Background(), blk); err != nil {
+					return err
+				}
+			}
+		}()
+		if err != nil {
+			return nil, fmt.Errorf("error loading file %s: %w", file, err)
+		}
+	}
+
+	return bs, nil
+}
+
+// valueStore is a read-only routing.ValueStore serving the IPNS record
+// fixtures.
+type valueStore map[string][]byte
+
+var _ routing.ValueStore = valueStore(nil)
+
+func loadValueStore(recordFiles []string) (valueStore, error) {
+	vs := valueStore{}
+
+	for _, file := range recordFiles {
+		// Records are stored as-is, without validation: some fixtures are
+		// deliberately broken and the gateway is expected to reject them.
+		// The file name is [pubkey](_anything)?.ipns-record
+		name, _, _ := strings.Cut(strings.TrimSuffix(filepath.Base(file), ".ipns-record"), "_")
+		id, err := peer.Decode(name)
+		if err != nil {
+			return nil, fmt.Errorf("error loading file %s: %w", file, err)
+		}
+
+		key := string(ipns.NameFromPeer(id).RoutingKey())
+		if _, ok := vs[key]; ok {
+			return nil, fmt.Errorf("collision detected for IPNS name %s", name)
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		vs[key] = data
+	}
+
+	return vs, nil
+}
+
+func (vs valueStore) PutValue(ctx context.Context, key string, value []byte, opts ...routing.Option) error {
+	return routing.ErrNotSupported
+}
+
+func (vs valueStore) GetValue(ctx context.Context, key string, opts ...routing.Option) ([]byte, error) {
+	value, ok := vs[key]
+	if !ok {
+		return nil, routing.ErrNotFound
+	}
+	return value, nil
+}
+
+func (vs valueStore) SearchValue(ctx context.Context, key string, opts ...routing.Option) (<-chan []byte, error) {
+	value, err := vs.GetValue(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan []byte, 1)
+	out <- value
+	close(out)
+	return out, nil
+}
+
+// dnsResolver answers DNSLink TXT lookups from the DNSLink fixtures and
+// refuses every other query, so the server never reaches the network.
+type dnsResolver map[string]string
+
+func loadDNSLinks(configFiles []string) (dnsResolver, error) {
+	agg, err := dnslink.Aggregate(configFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return dnsResolver(agg.Domains), nil
+}
+
+func (r dnsResolver) LookupIPAddr(ctx context.Context, domain string) ([]net.IPAddr, error) {
+	return nil, errors.New("IP address lookups are not supported")
+}
+
+func (r dnsResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	domain := strings.TrimSuffix(strings.TrimPrefix(name, "_dnslink."), ".")
+	path, ok := r[domain]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return []string{"dnslink=" + path}, nil
+}
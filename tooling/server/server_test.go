@@ -0,0 +1,82 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/fixtures"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	fxs, err := fixtures.List()
+	require.NoError(t, err)
+
+	handler, err := NewHandler(fxs, DefaultSubdomainHosts...)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, srv *httptest.Server, path, host string) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	require.NoError(t, err)
+	if host != "" {
+		req.Host = host
+	}
+
+	res, err := http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res, string(body)
+}
+
+func TestServesCarFixtures(t *testing.T) {
+	srv := newTestServer(t)
+
+	// CIDv1=$(echo "hello" | ipfs add --cid-version 1 -Q), see fixtures/dnslink.yml
+	res, body := get(t, srv, "/ipfs/bafkreicysg23kiwv34eg2d7qweipxwosdo2py4ldv42nbauguluen5v6am", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "hello\n", body)
+	assert.Equal(t, "*", res.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestServesDNSLinkFixtures(t *testing.T) {
+	srv := newTestServer(t)
+
+	res, body := get(t, srv, "/", "dnslink-test.example.org")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "hello\n", body)
+
+	res, _ = get(t, srv, "/", "unknown.example.org")
+	assert.NotEqual(t, http.StatusOK, res.StatusCode)
+}
+
+func TestServesIPNSRecordFixtures(t *testing.T) {
+	srv := newTestServer(t)
+
+	// fixtures/ipns_records/k51qzi5uqu5dlkw8pxuw9qmqayfdeh4kfebhmreauqdc6a7c3y7d5i9fi8mk9w_v1-v2.ipns-record
+	res, body := get(t, srv, "/ipns/k51qzi5uqu5dlkw8pxuw9qmqayfdeh4kfebhmreauqdc6a7c3y7d5i9fi8mk9w", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "v1+v2 record", body)
+
+	res, _ = get(t, srv, "/ipns/k51qzi5uqu5dlkw8pxuw9qmqayfdeh4kfebhmreauqdc6a7c3y7d5i9fi8mk9w?format=ipns-record", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/vnd.ipfs.ipns-record", res.Header.Get("Content-Type"))
+}
+
+func TestServesSubdomainGateway(t *testing.T) {
+	srv := newTestServer(t)
+
+	res, body := get(t, srv, "/", "bafkreicysg23kiwv34eg2d7qweipxwosdo2py4ldv42nbauguluen5v6am.ipfs.example.com")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "hello\n", body)
+}
@@ -108,6 +108,7 @@ var (
 	TrustlessGatewayRaw         = Leaf{"trustless-block-gateway", stable}
 	TrustlessGatewayCAR         = Leaf{"trustless-car-gateway", stable}
 	TrustlessGatewayCAROptional = Leaf{"trustless-car-gateway-optional", stable}
+	TrustlessGatewayCARv2       = Leaf{"trustless-car-v2-gateway", draft}
 	TrustlessGatewayIPNS        = Leaf{"trustless-ipns-gateway", stable}
 	TrustlessGateway            = Collection{"trustless-gateway", []Spec{TrustlessGatewayRaw, TrustlessGatewayCAR, TrustlessGatewayCAROptional, TrustlessGatewayIPNS}}
 	PathGatewayUnixFS           = Leaf{"path-unixfs-gateway", stable}
@@ -123,6 +124,7 @@ var (
 	DNSLinkGateway              = Leaf{"dnslink-gateway", stable}
 	RedirectsFile               = Leaf{"redirects-file", stable}
 	ProxyGateway                = Leaf{"proxy-gateway", stable}
+	SyntheticFixtures           = Leaf{"synthetic-fixtures", draft}
 )
 
 // All specs MUST be listed here.
@@ -130,6 +132,7 @@ var specs = []Spec{
 	TrustlessGatewayRaw,
 	TrustlessGatewayCAR,
 	TrustlessGatewayCAROptional,
+	TrustlessGatewayCARv2,
 	TrustlessGatewayIPNS,
 	TrustlessGateway,
 	PathGatewayUnixFS,
@@ -145,6 +148,7 @@ var specs = []Spec{
 	DNSLinkGateway,
 	RedirectsFile,
 	ProxyGateway,
+	SyntheticFixtures,
 }
 
 var specEnabled = map[Spec]bool{}
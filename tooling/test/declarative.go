@@ -0,0 +1,573 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/car"
+	"github.com/ipfs/gateway-conformance/tooling/check"
+	"github.com/ipfs/gateway-conformance/tooling/fixtures"
+	"github.com/ipfs/gateway-conformance/tooling/specs"
+	"gopkg.in/yaml.v3"
+)
+
+// SugarTestPaths are the YAML and JSON files, or directories of such files,
+// of the declarative tests to run, see the -tests flag.
+var SugarTestPaths []string
+
+// SugarTestFile is a file of SugarTests written in YAML or JSON, see
+// docs/test-dsl-syntax.md for the syntax.
+type SugarTestFile struct {
+	Name     string
+	Path     string
+	Required []specs.Leaf
+	Tests    SugarTests
+}
+
+// Run runs the tests of the file, they are skipped when a required spec is
+// disabled.
+func (f *SugarTestFile) Run(t *testing.T) {
+	t.Helper()
+	RunWithSpecs(t, f.Tests, f.Required...)
+}
+
+type declarativeFile struct {
+	Name     string            `yaml:"name"`
+	Specs    []string          `yaml:"specs"`
+	Fixtures map[string]string `yaml:"fixtures"`
+	Tests    []declarativeTest `yaml:"tests"`
+}
+
+type declarativeTest struct {
+	Name      string                `yaml:"name"`
+	Hint      string                `yaml:"hint"`
+	Spec      string                `yaml:"spec"`
+	Specs     []string              `yaml:"specs"`
+	Request   *declarativeRequest   `yaml:"request"`
+	Requests  []declarativeRequest  `yaml:"requests"`
+	Response  *declarativeResponse  `yaml:"response"`
+	Responses *declarativeResponses `yaml:"responses"`
+	Steps     []declarativeStep     `yaml:"steps"`
+}
+
+type declarativeStep struct {
+	Name     string               `yaml:"name"`
+	Request  declarativeRequest   `yaml:"request"`
+	Response *declarativeResponse `yaml:"response"`
+	Capture  []declarativeCapture `yaml:"capture"`
+}
+
+type declarativeCapture struct {
+	Name     string `yaml:"name"`
+	Header   string `yaml:"header"`
+	Body     bool   `yaml:"body"`
+	Matches  string `yaml:"matches"`
+	Optional bool   `yaml:"optional"`
+}
+
+type declarativeRequest struct {
+	Method          string            `yaml:"method"`
+	Path            string            `yaml:"path"`
+	Query           map[string]string `yaml:"query"`
+	Headers         map[string]string `yaml:"headers"`
+	Body            *string           `yaml:"body"`
+	FollowRedirects bool              `yaml:"followRedirects"`
+	Proxy           string            `yaml:"proxy"`
+	UseProxyTunnel  bool              `yaml:"useProxyTunnel"`
+}
+
+type declarativeResponse struct {
+	Status       int                 `yaml:"status"`
+	Headers      []declarativeHeader `yaml:"headers"`
+	Body         *string             `yaml:"body"`
+	BodyContains *string             `yaml:"bodyContains"`
+	BodyMatches  *string             `yaml:"bodyMatches"`
+	BodyFixture  string              `yaml:"bodyFixture"`
+	BodyHint     string              `yaml:"bodyHint"`
+	Specs        []string            `yaml:"specs"`
+}
+
+type declarativeHeader struct {
+	Key      string   `yaml:"key"`
+	Equals   *string  `yaml:"equals"`
+	Contains *string  `yaml:"contains"`
+	Matches  *string  `yaml:"matches"`
+	Exists   bool     `yaml:"exists"`
+	Empty    bool     `yaml:"empty"`
+	Not      bool     `yaml:"not"`
+	Hint     string   `yaml:"hint"`
+	Specs    []string `yaml:"specs"`
+}
+
+type declarativeResponses struct {
+	HaveTheSamePayload bool `yaml:"haveTheSamePayload"`
+}
+
+// LoadSugarTestFiles loads the files, and the .yml, .yaml and .json files
+// found in the directories, sorted by path.
+func LoadSugarTestFiles(paths ...string) ([]*SugarTestFile, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		var found []string
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch filepath.Ext(path) {
+			case ".yml", ".yaml", ".json":
+				if !d.IsDir() {
+					found = append(found, path)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		slices.Sort(found)
+		files = append(files, found...)
+	}
+
+	var loaded []*SugarTestFile
+	for _, file := range files {
+		f, err := LoadSugarTests(file)
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, f)
+	}
+	return loaded, nil
+}
+
+// LoadSugarTests loads a file of SugarTests written in YAML or JSON.
+func LoadSugarTests(path string) (*SugarTestFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var d declarativeFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&d); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	f, err := d.sugarTests(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", path, err)
+	}
+	return f, nil
+}
+
+func (d *declarativeFile) sugarTests(path string) (*SugarTestFile, error) {
+	f := &SugarTestFile{
+		Name: d.Name,
+		Path: path,
+	}
+	if f.Name == "" {
+		f.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	for _, name := range d.Specs {
+		spec, err := specs.FromString(name)
+		if err != nil {
+			return nil, err
+		}
+		leaf, ok := spec.(specs.Leaf)
+		if !ok {
+			return nil, fmt.Errorf("spec %s is not a leaf spec", name)
+		}
+		f.Required = append(f.Required, leaf)
+	}
+
+	r := &fixtureResolver{fixtures: map[string]*car.UnixfsDag{}}
+	for name, file := range d.Fixtures {
+		fixture, err := openFixture(path, file)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", name, err)
+		}
+		r.fixtures[name] = fixture
+	}
+
+	if len(d.Tests) == 0 {
+		return nil, errors.New("no tests")
+	}
+	for i, dt := range d.Tests {
+		test, err := dt.sugarTest(r)
+		if err != nil {
+			return nil, fmt.Errorf("test %d (%s): %w", i, dt.Name, err)
+		}
+		f.Tests = append(f.Tests, test)
+	}
+
+	return f, nil
+}
+
+// openFixture opens a CAR file relative to the fixtures directory, or to the
+// test file when it starts with ./ or ../.
+func openFixture(testFile, file string) (*car.UnixfsDag, error) {
+	p := filepath.Join(fixtures.Dir(), file)
+	if strings.HasPrefix(file, "./") || strings.HasPrefix(file, "../") {
+		abs, err := filepath.Abs(filepath.Join(filepath.Dir(testFile), file))
+		if err != nil {
+			return nil, err
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(wd, abs)
+		if err != nil {
+			return nil, err
+		}
+		p = rel
+		file = "./" + rel
+	}
+
+	// MustOpenUnixfsCar exits on errors, report the most likely one here.
+	if _, err := os.Stat(p); err != nil {
+		return nil, err
+	}
+	return car.MustOpenUnixfsCar(file), nil
+}
+
+type fixtureResolver struct {
+	fixtures map[string]*car.UnixfsDag
+	// vars are the variables captured by the previous steps of a scenario,
+	// their placeholders are left as is.
+	vars map[string]bool
+}
+
+var fixtureRefRe = regexp.MustCompile(`{{\s*([^{}\s]+)\s*}}`)
+
+// cid resolves a fixture reference: "name" is the CID of the root of the
+// fixture, "name/a/b" the CID of the node at the path a/b.
+func (r *fixtureResolver) cid(ref string) (string, error) {
+	fixture, names, err := r.node(ref)
+	if err != nil {
+		return "", err
+	}
+	return fixture.MustGetCid(names...), nil
+}
+
+func (r *fixtureResolver) node(ref string) (f *car.UnixfsDag, names []string, err error) {
+	name, path, _ := strings.Cut(ref, "/")
+	fixture, ok := r.fixtures[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown fixture %q", name)
+	}
+	if path != "" {
+		names = strings.Split(path, "/")
+	}
+
+	defer func() {
+		// The car helpers panic on missing nodes.
+		if p := recover(); p != nil {
+			err = fmt.Errorf("fixture reference %q: %v", ref, p)
+		}
+	}()
+	fixture.MustGetNode(names...)
+	return fixture, names, nil
+}
+
+// expand replaces the {{fixture/path}} references of s with their CID.
+func (r *fixtureResolver) expand(s string) (string, error) {
+	var err error
+	s = fixtureRefRe.ReplaceAllStringFunc(s, func(m string) string {
+		ref := fixtureRefRe.FindStringSubmatch(m)[1]
+		if r.vars[ref] {
+			return Var(ref)
+		}
+		c, e := r.cid(ref)
+		if e != nil && err == nil {
+			err = e
+		}
+		return c
+	})
+	return s, err
+}
+
+func (dt *declarativeTest) sugarTest(r *fixtureResolver) (SugarTest, error) {
+	test := SugarTest{
+		Name:  dt.Name,
+		Hint:  dt.Hint,
+		Spec:  dt.Spec,
+		Specs: dt.Specs,
+	}
+
+	if dt.Name == "" {
+		return test, errors.New("missing name")
+	}
+	if dt.Spec != "" && len(dt.Specs) > 0 {
+		return test, errors.New("cannot have both spec and specs")
+	}
+	if len(dt.Steps) > 0 {
+		if dt.Request != nil || len(dt.Requests) > 0 || dt.Response != nil || dt.Responses != nil {
+			return test, errors.New("steps cannot be used with request(s) and response(s)")
+		}
+		steps, err := dt.steps(r)
+		test.Steps = steps
+		return test, err
+	}
+	if (dt.Request == nil) == (len(dt.Requests) == 0) {
+		return test, errors.New("expected either a request or requests")
+	}
+	if (dt.Response == nil) == (dt.Responses == nil) {
+		return test, errors.New("expected either a response or responses")
+	}
+	if dt.Responses != nil && len(dt.Requests) == 0 {
+		return test, errors.New("responses must be used with requests")
+	}
+
+	var err error
+	if dt.Request != nil {
+		if test.Request, err = dt.Request.requestBuilder(r); err != nil {
+			return test, err
+		}
+	}
+	for _, req := range dt.Requests {
+		rb, err := req.requestBuilder(r)
+		if err != nil {
+			return test, err
+		}
+		test.Requests = append(test.Requests, rb)
+	}
+
+	if dt.Response != nil {
+		if test.Response, err = dt.Response.expectBuilder(r); err != nil {
+			return test, err
+		}
+	}
+	if dt.Responses != nil {
+		test.Responses = Responses()
+		if dt.Responses.HaveTheSamePayload {
+			test.Responses = test.Responses.HaveTheSamePayload()
+		}
+	}
+
+	return test, nil
+}
+
+func (dt *declarativeTest) steps(r *fixtureResolver) ([]StepBuilder, error) {
+	r.vars = map[string]bool{}
+	defer func() { r.vars = nil }()
+
+	var steps []StepBuilder
+	for i, ds := range dt.Steps {
+		step := Step(ds.Name)
+
+		rb, err := ds.Request.requestBuilder(r)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		step = step.Request(rb)
+
+		if ds.Response != nil {
+			e, err := ds.Response.expectBuilder(r)
+			if err != nil {
+				return nil, fmt.Errorf("step %d: %w", i, err)
+			}
+			step = step.Response(e)
+		}
+
+		for _, dc := range ds.Capture {
+			c := Capture(dc.Name)
+			switch {
+			case dc.Name == "":
+				return nil, fmt.Errorf("step %d: missing capture name", i)
+			case dc.Header != "" && !dc.Body:
+				c = c.FromHeader(dc.Header)
+			case dc.Header == "" && dc.Body:
+				c = c.FromBody()
+			default:
+				return nil, fmt.Errorf("step %d: capture %s: expected either a header or body: true", i, dc.Name)
+			}
+			if dc.Matches != "" {
+				if _, err := regexp.Compile(dc.Matches); err != nil {
+					return nil, fmt.Errorf("step %d: capture %s: %w", i, dc.Name, err)
+				}
+				c = c.Matches(dc.Matches)
+			}
+			if dc.Optional {
+				c = c.Optional()
+			}
+			step = step.Capture(c)
+			r.vars[dc.Name] = true
+		}
+
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func (dr *declarativeRequest) requestBuilder(r *fixtureResolver) (RequestBuilder, error) {
+	rb := Request()
+
+	var err error
+	if rb.Path_, err = r.expand(dr.Path); err != nil {
+		return rb, err
+	}
+	if rb.Proxy_, err = r.expand(dr.Proxy); err != nil {
+		return rb, err
+	}
+	if dr.Method != "" {
+		rb.Method_ = dr.Method
+	}
+	rb.UseProxyTunnel_ = dr.UseProxyTunnel
+	rb.FollowRedirects_ = dr.FollowRedirects
+
+	for k, v := range dr.Query {
+		if v, err = r.expand(v); err != nil {
+			return rb, err
+		}
+		rb.Query_.Add(k, v)
+	}
+	if len(dr.Headers) > 0 {
+		rb.Headers_ = map[string]string{}
+		for k, v := range dr.Headers {
+			if v, err = r.expand(v); err != nil {
+				return rb, err
+			}
+			rb.Headers_[k] = v
+		}
+	}
+	if dr.Body != nil {
+		rb.Body_ = []byte(*dr.Body)
+	}
+
+	return rb, nil
+}
+
+func (dr *declarativeResponse) expectBuilder(r *fixtureResolver) (ExpectBuilder, error) {
+	e := Expect()
+
+	if dr.Status != 0 {
+		e = e.Status(dr.Status)
+	}
+
+	for _, dh := range dr.Headers {
+		h, err := dh.headerBuilder(r)
+		if err != nil {
+			return e, err
+		}
+		e = e.Header(h)
+	}
+
+	var body []any
+	if dr.Body != nil {
+		v, err := r.expand(*dr.Body)
+		if err != nil {
+			return e, err
+		}
+		body = append(body, check.IsEqual(v))
+	}
+	if dr.BodyContains != nil {
+		v, err := r.expand(*dr.BodyContains)
+		if err != nil {
+			return e, err
+		}
+		body = append(body, check.Contains(v))
+	}
+	if dr.BodyMatches != nil {
+		v, err := r.expand(*dr.BodyMatches)
+		if err != nil {
+			return e, err
+		}
+		body = append(body, check.Matches(v))
+	}
+	if dr.BodyFixture != "" {
+		fixture, names, err := r.node(dr.BodyFixture)
+		if err != nil {
+			return e, err
+		}
+		body = append(body, fixture.MustGetRawData(names...))
+	}
+
+	switch {
+	case len(body) > 1:
+		return e, errors.New("expected at most one of body, bodyContains, bodyMatches and bodyFixture")
+	case len(body) == 1 && dr.BodyHint != "":
+		c, ok := body[0].(check.Check[string])
+		if !ok {
+			return e, errors.New("bodyHint cannot be used with bodyFixture")
+		}
+		e = e.BodyWithHint(dr.BodyHint, c)
+	case len(body) == 1:
+		e = e.Body(body[0])
+	case dr.BodyHint != "":
+		return e, errors.New("bodyHint without a body check")
+	}
+
+	if len(dr.Specs) > 0 {
+		e = e.Specs(dr.Specs...)
+	}
+	return e, nil
+}
+
+func (dh *declarativeHeader) headerBuilder(r *fixtureResolver) (HeaderBuilder, error) {
+	if dh.Key == "" {
+		return HeaderBuilder{}, errors.New("missing header key")
+	}
+	h := Header(dh.Key)
+
+	checks := 0
+	for _, c := range []struct {
+		value *string
+		set   func(string, ...any) HeaderBuilder
+	}{
+		{dh.Equals, h.Equals},
+		{dh.Contains, h.Contains},
+		{dh.Matches, h.Matches},
+	} {
+		if c.value == nil {
+			continue
+		}
+		v, err := r.expand(*c.value)
+		if err != nil {
+			return h, err
+		}
+		h = c.set(v)
+		checks++
+	}
+	if dh.Exists {
+		h = h.Exists()
+		checks++
+	}
+	if dh.Empty {
+		h = h.IsEmpty()
+		checks++
+	}
+	if checks != 1 {
+		return h, fmt.Errorf("header %s: expected exactly one of equals, contains, matches, exists and empty", dh.Key)
+	}
+
+	if dh.Not {
+		h = h.Not()
+	}
+	if dh.Hint != "" {
+		h = h.Hint(dh.Hint)
+	}
+	if len(dh.Specs) > 0 {
+		h = h.Specs(dh.Specs...)
+	}
+	return h, nil
+}
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/gateway-conformance/tooling/car"
+	"github.com/ipfs/gateway-conformance/tooling/specs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const declarativeYAML = `
+name: VendorRegressions
+specs: [path-unixfs-gateway]
+fixtures:
+  dir: path_gateway_unixfs/dir-with-files.car
+tests:
+  - name: GET a file
+    spec: https://specs.ipfs.tech/http-gateways/path-gateway/
+    request:
+      path: /ipfs/{{dir}}/ascii.txt
+      query:
+        filename: "{{ dir/ascii.txt }}.txt"
+      headers:
+        Accept: text/plain
+    response:
+      status: 200
+      headers:
+        - key: Etag
+          equals: '"{{dir/ascii.txt}}"'
+        - key: X-Vendor
+          exists: true
+          not: true
+      bodyFixture: dir/ascii.txt
+  - name: POST is not allowed
+    request:
+      method: POST
+      path: /ipfs/{{dir}}
+      body: hello
+    response:
+      status: 405
+      bodyContains: not allowed
+      bodyHint: Gateways are read-only
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadSugarTests(t *testing.T) {
+	fixture := car.MustOpenUnixfsCar("path_gateway_unixfs/dir-with-files.car")
+
+	f, err := LoadSugarTests(writeFile(t, "vendor.yml", declarativeYAML))
+	require.NoError(t, err)
+
+	assert.Equal(t, "VendorRegressions", f.Name)
+	assert.Equal(t, []specs.Leaf{specs.PathGatewayUnixFS}, f.Required)
+	require.Len(t, f.Tests, 2)
+
+	get := f.Tests[0]
+	assert.Equal(t, "GET a file", get.Name)
+	assert.Equal(t, []string{"https://specs.ipfs.tech/http-gateways/path-gateway/"}, get.AllSpecs())
+	assert.Equal(t, "GET", get.Request.Method_)
+	assert.Equal(t, "/ipfs/"+fixture.MustGetCid()+"/ascii.txt", get.Request.Path_)
+	assert.Equal(t, fixture.MustGetCid("ascii.txt")+".txt", get.Request.Query_.Get("filename"))
+	assert.Equal(t, map[string]string{"Accept": "text/plain"}, get.Request.Headers_)
+
+	expect := get.Response.(ExpectBuilder)
+	assert.Equal(t, 200, expect.StatusCode_)
+	require.Len(t, expect.Headers_, 2)
+	assert.True(t, expect.Headers_[0].Check_.Check([]string{`"` + fixture.MustGetCid("ascii.txt") + `"`}).Success)
+	// exists + not: the header must be absent.
+	assert.False(t, expect.Headers_[1].Not_)
+	assert.True(t, expect.Headers_[1].Check_.Check(nil).Success)
+	assert.Equal(t, fixture.MustGetRawData("ascii.txt"), expect.Body_)
+
+	post := f.Tests[1]
+	assert.Equal(t, "POST", post.Request.Method_)
+	assert.Equal(t, []byte("hello"), post.Request.Body_)
+	expect = post.Response.(ExpectBuilder)
+	assert.Equal(t, 405, expect.StatusCode_)
+}
+
+func TestLoadSugarTestsJSON(t *testing.T) {
+	f, err := LoadSugarTests(writeFile(t, "vendor.json", `{
+		"tests": [{
+			"name": "same payload",
+			"requests": [{"path": "/a"}, {"path": "/b"}],
+			"responses": {"haveTheSamePayload": true}
+		}]
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "vendor", f.Name)
+	require.Len(t, f.Tests, 1)
+	assert.Len(t, f.Tests[0].Requests, 2)
+	assert.True(t, f.Tests[0].Responses.payloadsAreEquals)
+}
+
+func TestLoadSugarTestsErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown field":      "tests: [{name: a, request: {path: /}, response: {status: 200, statuss: 200}}]",
+		"no tests":           "name: empty",
+		"collection spec":    "specs: [path-gateway]\ntests: [{name: a, request: {path: /}, response: {status: 200}}]",
+		"unknown fixture":    "tests: [{name: a, request: {path: '/ipfs/{{dir}}'}, response: {status: 200}}]",
+		"missing fixture":    "fixtures: {dir: nope.car}\ntests: [{name: a, request: {path: /}, response: {status: 200}}]",
+		"missing node":       "fixtures: {dir: path_gateway_unixfs/dir-with-files.car}\ntests: [{name: a, request: {path: '/ipfs/{{dir/nope}}'}, response: {status: 200}}]",
+		"missing response":   "tests: [{name: a, request: {path: /}}]",
+		"two body checks":    "tests: [{name: a, request: {path: /}, response: {body: a, bodyContains: a}}]",
+		"two header checks":  "tests: [{name: a, request: {path: /}, response: {headers: [{key: A, equals: a, exists: true}]}}]",
+		"responses, request": "tests: [{name: a, request: {path: /}, responses: {haveTheSamePayload: true}}]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadSugarTests(writeFile(t, "test.yml", content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRunSugarTestFile(t *testing.T) {
+	fixture := car.MustOpenUnixfsCar("path_gateway_unixfs/dir-with-files.car")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Etag", `"`+fixture.MustGetCid("ascii.txt")+`"`)
+		w.Write(fixture.MustGetRawData("ascii.txt"))
+	}))
+	defer server.Close()
+	t.Setenv("GATEWAY_URL", server.URL)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor.yml"), []byte(declarativeYAML), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a test"), 0644))
+
+	files, err := LoadSugarTestFiles(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	files[0].Run(t)
+}
+
+func TestLoadSugarTestsSteps(t *testing.T) {
+	f, err := LoadSugarTests(writeFile(t, "steps.yml", `
+fixtures:
+  dir: path_gateway_unixfs/dir-with-files.car
+tests:
+  - name: revalidate
+    steps:
+      - name: GET
+        request: {path: "/ipfs/{{dir}}/ascii.txt"}
+        response: {status: 200}
+        capture:
+          - {name: etag, header: Etag}
+          - {name: root, header: X-Ipfs-Roots, matches: "^([^,]+)"}
+      - request:
+          path: /ipfs/{{root}}/ascii.txt
+          headers: {If-None-Match: "{{etag}}"}
+        response: {status: 304}
+`))
+	require.NoError(t, err)
+	require.Len(t, f.Tests, 1)
+
+	steps := f.Tests[0].Steps
+	require.Len(t, steps, 2)
+	assert.Equal(t, "GET", steps[0].Name_)
+	assert.Equal(t, []CaptureBuilder{
+		Capture("etag").FromHeader("Etag"),
+		Capture("root").FromHeader("X-Ipfs-Roots").Matches("^([^,]+)"),
+	}, steps[0].Capture_)
+	assert.Equal(t, "/ipfs/{{root}}/ascii.txt", steps[1].Request_.Path_)
+	assert.Equal(t, "{{etag}}", steps[1].Request_.Headers_["If-None-Match"])
+
+	// A variable is only known after the step capturing it.
+	_, err = LoadSugarTests(writeFile(t, "steps.yml", `
+tests:
+  - name: a
+    steps:
+      - request: {path: "/{{etag}}"}
+        capture: [{name: etag, header: Etag}]
+`))
+	assert.ErrorContains(t, err, `unknown fixture "etag"`)
+}
@@ -0,0 +1,296 @@
+package test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ipfs/gateway-conformance/tooling"
+)
+
+var (
+	// RecordDir is the directory where every HTTP exchange made by the tests
+	// is stored as a HAR file, see the -record flag. Empty to disable.
+	RecordDir = ""
+	// ReplayDir is a directory of exchanges stored with RecordDir. When set,
+	// the tests are validated against the stored responses instead of
+	// querying the gateway, see the -replay flag.
+	ReplayDir = ""
+)
+
+// HAR 1.2 (http://www.softwareishard.com/blog/har-12-spec/), limited to the
+// fields needed to replay an exchange. It can be opened in browser devtools.
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Error is the transport error of a request without response.
+	Error string `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harContent    `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	headers := []harNameValue{}
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			headers = append(headers, harNameValue{Name: k, Value: v})
+		}
+	}
+	return headers
+}
+
+func newHarContent(body []byte, mimeType string) harContent {
+	c := harContent{Size: len(body), MimeType: mimeType}
+	if utf8.Valid(body) {
+		c.Text = string(body)
+	} else {
+		c.Text = base64.StdEncoding.EncodeToString(body)
+		c.Encoding = "base64"
+	}
+	return c
+}
+
+func (c harContent) bytes() ([]byte, error) {
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return []byte(c.Text), nil
+}
+
+var (
+	exchangesMu sync.Mutex
+	exchanges   = map[string]int{}
+)
+
+// exchangePath returns the file of the next exchange of the test: tests that
+// send several requests store one file per request, in order.
+func exchangePath(dir string, t *testing.T) string {
+	exchangesMu.Lock()
+	n := exchanges[t.Name()]
+	exchanges[t.Name()]++
+	exchangesMu.Unlock()
+
+	segments := []string{dir}
+	for _, segment := range strings.Split(t.Name(), "/") {
+		segments = append(segments, escapeFileName(segment))
+	}
+	segments = append(segments, fmt.Sprintf("%d.har", n))
+	return filepath.Join(segments...)
+}
+
+// escapeFileName percent-encodes the characters that are not safe in a file
+// name on every platform.
+func escapeFileName(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.' && b.Len() > 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// recordExchange stores the exchange in a HAR file. The response body is read
+// and replaced so the validators can still consume it.
+func recordExchange(path string, req *http.Request, reqBody []byte, res *http.Response, resErr error, started time.Time) error {
+	entry := harEntry{
+		StartedDateTime: started,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+	if req.Host != "" && req.Header.Get("Host") == "" {
+		entry.Request.Headers = append(entry.Request.Headers, harNameValue{Name: "Host", Value: req.Host})
+	}
+	for k, values := range req.URL.Query() {
+		for _, v := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: k, Value: v})
+		}
+	}
+	sort.SliceStable(entry.Request.QueryString, func(i, j int) bool {
+		return entry.Request.QueryString[i].Name < entry.Request.QueryString[j].Name
+	})
+	if reqBody != nil {
+		content := newHarContent(reqBody, req.Header.Get("Content-Type"))
+		entry.Request.PostData = &content
+	}
+
+	if resErr != nil {
+		entry.Error = resErr.Error()
+	} else {
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("reading the response body: %w", err)
+		}
+
+		entry.Response.Status = res.StatusCode
+		entry.Response.StatusText = http.StatusText(res.StatusCode)
+		entry.Response.HTTPVersion = res.Proto
+		entry.Response.Headers = harHeaders(res.Header)
+		entry.Response.Content = newHarContent(body, res.Header.Get("Content-Type"))
+		entry.Response.RedirectURL = res.Header.Get("Location")
+		entry.Response.BodySize = res.ContentLength
+	}
+	entry.Time = float64(time.Since(started).Microseconds()) / 1000
+	entry.Timings.Wait = entry.Time
+
+	b, err := json.MarshalIndent(har{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "gateway-conformance", Version: tooling.Version},
+		Entries: []harEntry{entry},
+	}}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// loadExchange reads an exchange stored by recordExchange.
+func loadExchange(path string) (*harEntry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no recorded exchange: %w", err)
+	}
+
+	var h har
+	if err := json.Unmarshal(b, &h); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(h.Log.Entries) != 1 {
+		return nil, fmt.Errorf("%s: expected 1 entry, got %d", path, len(h.Log.Entries))
+	}
+	return &h.Log.Entries[0], nil
+}
+
+// mismatch describes how the request differs from the recorded one, e.g.
+// because the test changed since the exchange was recorded, or "".
+func (e *harEntry) mismatch(req *http.Request) string {
+	if e.Request.Method != req.Method || e.Request.URL != req.URL.String() {
+		return fmt.Sprintf("the request %s %s does not match the recorded request %s %s", req.Method, req.URL, e.Request.Method, e.Request.URL)
+	}
+	return ""
+}
+
+// response returns the recorded response, or the recorded transport error.
+func (e *harEntry) response(req *http.Request) (*http.Response, error) {
+	if e.Error != "" {
+		return nil, errors.New(e.Error)
+	}
+
+	body, err := e.Response.Content.bytes()
+	if err != nil {
+		return nil, fmt.Errorf("decoding the recorded body: %w", err)
+	}
+
+	res := &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Response.Status, e.Response.StatusText),
+		StatusCode:    e.Response.Status,
+		Proto:         e.Response.HTTPVersion,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: e.Response.BodySize,
+		Request:       req,
+	}
+	if major, minor, ok := http.ParseHTTPVersion(res.Proto); ok {
+		res.ProtoMajor, res.ProtoMinor = major, minor
+	}
+	for _, header := range e.Response.Headers {
+		res.Header.Add(header.Name, header.Value)
+	}
+
+	return res, nil
+}
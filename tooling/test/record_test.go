@@ -0,0 +1,77 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplayExchange(t *testing.T) {
+	body := []byte{0x00, 0xff, 'c', 'a', 'r'} // not UTF-8, stored as base64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.ipld.car")
+		w.Header().Add("X-Ipfs-Roots", "bafy")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"/ipfs/bafy?format=car", nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "0.har")
+	require.NoError(t, recordExchange(path, req, nil, res, nil, time.Now()))
+
+	// The body is still readable by the validators.
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, b)
+
+	entry, err := loadExchange(path)
+	require.NoError(t, err)
+	assert.Empty(t, entry.mismatch(req))
+
+	replayed, err := entry.response(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, replayed.StatusCode)
+	assert.Equal(t, "206 Partial Content", replayed.Status)
+	assert.Equal(t, 1, replayed.ProtoMajor)
+	assert.Equal(t, "bafy", replayed.Header.Get("X-Ipfs-Roots"))
+	assert.Equal(t, int64(len(body)), replayed.ContentLength)
+	b, err = io.ReadAll(replayed.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, b)
+
+	other, err := http.NewRequest("HEAD", server.URL+"/ipfs/bafy", nil)
+	require.NoError(t, err)
+	assert.Contains(t, entry.mismatch(other), "does not match the recorded request GET")
+}
+
+func TestReplayTransportError(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://127.0.0.1:1/ipfs/bafy", nil)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "0.har")
+	require.NoError(t, recordExchange(path, req, nil, nil, errors.New("connection refused"), time.Now()))
+
+	entry, err := loadExchange(path)
+	require.NoError(t, err)
+	_, err = entry.response(req)
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestExchangePath(t *testing.T) {
+	assert.Equal(t, filepath.Join("dir", "TestExchangePath", "0.har"), exchangePath("dir", t))
+	assert.Equal(t, filepath.Join("dir", "TestExchangePath", "1.har"), exchangePath("dir", t))
+	assert.Equal(t, "GET_%2Fipfs%2F_with_Accept%3A_%2A", escapeFileName("GET_/ipfs/_with_Accept:_*"))
+	assert.Equal(t, "%2E.", escapeFileName(".."))
+}
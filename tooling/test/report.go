@@ -3,25 +3,40 @@ package test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"testing"
 	"text/template"
+
+	"github.com/ipfs/gateway-conformance/tooling/check"
 )
 
 type ReportInput struct {
 	Req  *http.Request
 	Res  *http.Response
 	Err  error
+	Diff *check.Diff
 	Test SugarTest
 }
 
+// checkError is the error of a failed check, it brings the diff of the check
+// to the report.
+type checkError check.CheckOutput
+
+func (e checkError) Error() string { return e.Reason }
+
 const TEMPLATE = `
 Name: {{.Test.Name}}
 Hint: {{.Test.Hint}}
 
 Error: {{.Err}}
+{{- with .Diff}}
+
+Diff:
+{{.}}
+{{- end}}
 
 Expected Request:
 {{.Test.Request | json}}
@@ -46,6 +61,11 @@ func report(t *testing.T, test SugarTest, req *http.Request, res *http.Response,
 		Test: test,
 	}
 
+	var ce checkError
+	if errors.As(err, &ce) {
+		input.Diff = ce.Diff
+	}
+
 	tmpl, err := template.New("report").Funcs(template.FuncMap{
 		"json": func(v any) string {
 			j, _ := json.MarshalIndent(v, "", "  ")
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ipfs/gateway-conformance/tooling"
+)
+
+var (
+	// Retries is the number of times a request is re-issued after a
+	// transport error or a 5xx response, see the -retries flag.
+	Retries = 0
+	// RetryGETOnly limits the retries to GET and HEAD requests, see the
+	// -retry-get-only flag.
+	RetryGETOnly = false
+	// RetryDelay is the delay before the first retry, doubled after every
+	// attempt.
+	RetryDelay = 500 * time.Millisecond
+)
+
+// expectsServerError returns true when the validator accepts a 5xx status:
+// such responses are the expected outcome and must not be retried.
+func expectsServerError(v ExpectValidator) bool {
+	switch v := v.(type) {
+	case ExpectBuilder:
+		return v.StatusCode_ >= 500 || v.StatusCodeTo_ >= 500
+	case AllOfExpectBuilder:
+		for _, e := range v.Expect_ {
+			if expectsServerError(e) {
+				return true
+			}
+		}
+	case AnyOfExpectBuilder:
+		for _, e := range v.Expect_ {
+			if expectsServerError(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// retryReason returns why the request should be retried, or "".
+func retryReason(req *http.Request, res *http.Response, err error, expect5xx bool) string {
+	if RetryGETOnly && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return ""
+	}
+	switch {
+	case err != nil:
+		return err.Error()
+	case res.StatusCode >= 500 && !expect5xx:
+		return res.Status
+	default:
+		return ""
+	}
+}
+
+// do sends the request, retrying it up to Retries times on transport errors
+// and unexpected 5xx responses. Retried tests log a "retries" metadata, the
+// reports mark them as flaky when they pass.
+func do(ctx context.Context, t *testing.T, client *http.Client, req *http.Request, body []byte, expect5xx bool) (*http.Response, error) {
+	delay := RetryDelay
+	for attempt := 0; ; attempt++ {
+		res, err := client.Do(req)
+
+		reason := retryReason(req, res, err, expect5xx)
+		if attempt >= Retries || reason == "" {
+			if attempt > 0 {
+				tooling.LogMetadata(t, struct {
+					Retries int `json:"retries"`
+				}{
+					Retries: attempt,
+				})
+			}
+			return res, err
+		}
+
+		t.Logf("Querying %s failed: %s, retrying (%d/%d)", req.URL, reason, attempt+1, Retries)
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s, and retrying failed: %w", reason, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+	}
+}
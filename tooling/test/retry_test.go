@@ -0,0 +1,85 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoRetries(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first two requests.
+		if hits.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	defer func(retries int, getOnly bool, delay time.Duration) {
+		Retries, RetryGETOnly, RetryDelay = retries, getOnly, delay
+	}(Retries, RetryGETOnly, RetryDelay)
+	RetryDelay = time.Millisecond
+
+	send := func(method string, expect5xx bool) int {
+		hits.Store(0)
+		req, err := http.NewRequest(method, server.URL, nil)
+		require.NoError(t, err)
+		res, err := do(context.Background(), t, http.DefaultClient, req, nil, expect5xx)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	Retries = 0
+	assert.Equal(t, http.StatusServiceUnavailable, send("GET", false))
+
+	Retries = 1
+	assert.Equal(t, http.StatusServiceUnavailable, send("GET", false))
+	assert.Equal(t, int32(2), hits.Load())
+
+	Retries = 2
+	assert.Equal(t, http.StatusOK, send("GET", false))
+	assert.Equal(t, int32(3), hits.Load())
+
+	// The test expects a 5xx.
+	assert.Equal(t, http.StatusServiceUnavailable, send("GET", true))
+	assert.Equal(t, int32(1), hits.Load())
+
+	RetryGETOnly = true
+	assert.Equal(t, http.StatusServiceUnavailable, send("POST", false))
+	assert.Equal(t, http.StatusOK, send("HEAD", false))
+}
+
+func TestDoRetriesTransportErrors(t *testing.T) {
+	defer func(retries int, delay time.Duration) {
+		Retries, RetryDelay = retries, delay
+	}(Retries, RetryDelay)
+	Retries, RetryDelay = 3, time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = do(context.Background(), t, http.DefaultClient, req, nil, false)
+	assert.Error(t, err)
+	// 1ms + 2ms + 4ms of backoff.
+	assert.GreaterOrEqual(t, time.Since(start), 7*time.Millisecond)
+}
+
+func TestExpectsServerError(t *testing.T) {
+	assert.False(t, expectsServerError(Expect().Status(200)))
+	assert.True(t, expectsServerError(Expect().Status(504)))
+	assert.True(t, expectsServerError(Expect().StatusBetween(400, 599)))
+	assert.True(t, expectsServerError(AnyOf(Expect().Status(200), Expect().Status(502))))
+	assert.True(t, expectsServerError(AllOf(Expect().Status(500))))
+}
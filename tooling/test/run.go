@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ipfs/gateway-conformance/tooling"
 )
@@ -34,6 +35,8 @@ func runRequest(ctx context.Context, t *testing.T, test SugarTest, builder Reque
 		client = NewProxyClient(builder.Proxy_)
 	}
 
+	client.Timeout = RequestTimeout
+
 	// Handle redirect tests
 	if !builder.FollowRedirects_ {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
@@ -116,9 +119,37 @@ func runRequest(ctx context.Context, t *testing.T, test SugarTest, builder Reque
 	log.Debugf("Querying %s", url)
 	req = req.WithContext(ctx)
 
-	res, err = client.Do(req)
-	if err != nil {
-		localReport(t, "Querying %s failed: %s", url, err)
+	expect5xx := test.Response != nil && expectsServerError(test.Response)
+
+	switch {
+	case ReplayDir != "":
+		path := exchangePath(ReplayDir, t)
+		entry, err := loadExchange(path)
+		if err != nil {
+			localReport(t, "Replaying %s failed: %s", url, err)
+		}
+		if mismatch := entry.mismatch(req); mismatch != "" {
+			t.Logf("%s: %s", path, mismatch)
+		}
+		res, err = entry.response(req)
+		if err != nil {
+			localReport(t, "Querying %s failed: %s", url, err)
+		}
+	case RecordDir != "":
+		path := exchangePath(RecordDir, t)
+		started := time.Now()
+		res, err = do(ctx, t, client, req, builder.Body_, expect5xx)
+		if recErr := recordExchange(path, req, builder.Body_, res, err, started); recErr != nil {
+			t.Logf("Recording %s failed: %s", path, recErr)
+		}
+		if err != nil {
+			localReport(t, "Querying %s failed: %s", url, err)
+		}
+	default:
+		res, err = do(ctx, t, client, req, builder.Body_, expect5xx)
+		if err != nil {
+			localReport(t, "Querying %s failed: %s", url, err)
+		}
 	}
 
 	return req, res, localReport
@@ -0,0 +1,232 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// StepBuilder is a step of a scenario (see SugarTest.Steps): a request, the
+// expected response, and the values captured from the response for the
+// following steps.
+type StepBuilder struct {
+	Name_     string           `json:"name,omitempty"`
+	Request_  RequestBuilder   `json:"request"`
+	Response_ ExpectValidator  `json:"response,omitempty"`
+	Capture_  []CaptureBuilder `json:"capture,omitempty"`
+}
+
+func Step(name string) StepBuilder {
+	return StepBuilder{Name_: name, Request_: Request()}
+}
+
+func (s StepBuilder) Request(r RequestBuilder) StepBuilder {
+	s.Request_ = r
+	return s
+}
+
+func (s StepBuilder) Response(e ExpectValidator) StepBuilder {
+	s.Response_ = e
+	return s
+}
+
+func (s StepBuilder) Capture(cs ...CaptureBuilder) StepBuilder {
+	s.Capture_ = append(s.Capture_, cs...)
+	return s
+}
+
+// CaptureBuilder captures a value of a response, from a header or the body,
+// into a variable of the scenario.
+type CaptureBuilder struct {
+	Name_     string `json:"name"`
+	Header_   string `json:"header,omitempty"`
+	Body_     bool   `json:"body,omitempty"`
+	Matches_  string `json:"matches,omitempty"`
+	Optional_ bool   `json:"optional,omitempty"`
+}
+
+func Capture(name string) CaptureBuilder {
+	return CaptureBuilder{Name_: name}
+}
+
+func (c CaptureBuilder) FromHeader(key string) CaptureBuilder {
+	c.Header_ = key
+	c.Body_ = false
+	return c
+}
+
+func (c CaptureBuilder) FromBody() CaptureBuilder {
+	c.Header_ = ""
+	c.Body_ = true
+	return c
+}
+
+// Matches captures the first group of the regular expression, or the whole
+// match when it has no group, instead of the whole value.
+func (c CaptureBuilder) Matches(re string) CaptureBuilder {
+	c.Matches_ = re
+	return c
+}
+
+// Optional does not fail the step when the value is missing, the steps using
+// the variable are skipped instead.
+func (c CaptureBuilder) Optional() CaptureBuilder {
+	c.Optional_ = true
+	return c
+}
+
+func (c CaptureBuilder) capture(header http.Header, body []byte) (string, error) {
+	var value string
+	switch {
+	case c.Header_ != "":
+		values := header.Values(c.Header_)
+		if len(values) == 0 {
+			return "", fmt.Errorf("header %s is missing", c.Header_)
+		}
+		value = strings.Join(values, ", ")
+	case c.Body_:
+		value = string(body)
+	default:
+		return "", fmt.Errorf("capture %s has no source, use FromHeader or FromBody", c.Name_)
+	}
+
+	if c.Matches_ == "" {
+		return value, nil
+	}
+	re, err := regexp.Compile(c.Matches_)
+	if err != nil {
+		return "", err
+	}
+	m := re.FindStringSubmatch(value)
+	switch {
+	case m == nil:
+		return "", fmt.Errorf("%q does not match %q", value, c.Matches_)
+	case len(m) > 1:
+		return m[1], nil
+	default:
+		return m[0], nil
+	}
+}
+
+// Var is the placeholder of a variable captured by a previous step, e.g.
+// Header("If-None-Match", "{{etag}}", Var("etag")). Placeholders are replaced
+// when the step is run.
+func Var(name string) string {
+	return "{{" + name + "}}"
+}
+
+// mapStrings returns a clone of the request with f applied to its path,
+// proxy, query values, header values and body.
+func (r RequestBuilder) mapStrings(f func(string) string) RequestBuilder {
+	r = r.Clone()
+	r.Path_ = f(r.Path_)
+	r.Proxy_ = f(r.Proxy_)
+	for _, values := range r.Query_ {
+		for i, v := range values {
+			values[i] = f(v)
+		}
+	}
+	for k, v := range r.Headers_ {
+		r.Headers_[k] = f(v)
+	}
+	if r.Body_ != nil {
+		r.Body_ = []byte(f(string(r.Body_)))
+	}
+	return r
+}
+
+// expand replaces the placeholders of the captured variables in the request.
+func (r RequestBuilder) expand(vars map[string]string) RequestBuilder {
+	if len(vars) == 0 {
+		return r
+	}
+
+	pairs := make([]string, 0, 2*len(vars))
+	for name, value := range vars {
+		pairs = append(pairs, Var(name), value)
+	}
+	return r.mapStrings(strings.NewReplacer(pairs...).Replace)
+}
+
+// uses returns true when the request has the placeholder of the variable.
+func (r RequestBuilder) uses(name string) bool {
+	found := false
+	r.mapStrings(func(s string) string {
+		found = found || strings.Contains(s, Var(name))
+		return s
+	})
+	return found
+}
+
+// runSteps runs the steps of a scenario in order, each one as a subtest. The
+// variables of a failing step, and the missing optional ones, are not
+// captured: the steps using them are skipped.
+func runSteps(ctx context.Context, t *testing.T, test SugarTest) {
+	t.Helper()
+
+	vars := map[string]string{}
+	missing := map[string]string{}
+	for i, step := range test.Steps {
+		name := step.Name_
+		if name == "" {
+			name = fmt.Sprintf("Step %d", i+1)
+		}
+
+		ok := t.Run(safeName(name), func(t *testing.T) {
+			for v, reason := range missing {
+				if step.Request_.uses(v) {
+					t.Skipf("skipping step, %s was not captured: %s", v, reason)
+				}
+			}
+
+			stepTest := test
+			stepTest.Response = step.Response_
+
+			_, res, localReport := runRequest(ctx, t, stepTest, step.Request_.expand(vars))
+			defer res.Body.Close()
+
+			// The validators consume the body, keep a copy for the captures
+			// reading it.
+			var body []byte
+			if slices.ContainsFunc(step.Capture_, func(c CaptureBuilder) bool { return c.Body_ }) {
+				var err error
+				body, err = io.ReadAll(res.Body)
+				res.Body = io.NopCloser(bytes.NewReader(body))
+				if err != nil {
+					localReport(t, "Reading the body failed: %s", err)
+				}
+			}
+
+			if step.Response_ != nil {
+				step.Response_.Validate(t, res, localReport)
+			}
+
+			for _, c := range step.Capture_ {
+				value, err := c.capture(res.Header, body)
+				switch {
+				case err != nil && c.Optional_:
+					t.Logf("Not capturing %s: %s", c.Name_, err)
+					missing[c.Name_] = err.Error()
+				case err != nil:
+					localReport(t, "Capturing %s failed: %s", c.Name_, err)
+				default:
+					t.Logf("Captured %s: %q", c.Name_, value)
+					vars[c.Name_] = value
+					delete(missing, c.Name_)
+				}
+			}
+		})
+		if !ok {
+			for _, c := range step.Capture_ {
+				delete(vars, c.Name_)
+				missing[c.Name_] = fmt.Sprintf("step %q failed", name)
+			}
+		}
+	}
+}
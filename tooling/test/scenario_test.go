@@ -0,0 +1,112 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	header := http.Header{}
+	header.Set("Etag", `"bafy"`)
+	header.Set("X-Ipfs-Roots", "bafyroot,bafychild")
+	body := []byte(`{"Cid": "bafybody"}`)
+
+	for name, tc := range map[string]struct {
+		capture CaptureBuilder
+		value   string
+	}{
+		"header":          {Capture("etag").FromHeader("Etag"), `"bafy"`},
+		"header group":    {Capture("root").FromHeader("X-Ipfs-Roots").Matches(`^([^,]+)`), "bafyroot"},
+		"header no group": {Capture("root").FromHeader("X-Ipfs-Roots").Matches(`bafyc\w+`), "bafychild"},
+		"body":            {Capture("cid").FromBody().Matches(`"Cid": "(\w+)"`), "bafybody"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			value, err := tc.capture.capture(header, body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+
+	_, err := Capture("location").FromHeader("Location").capture(header, body)
+	assert.ErrorContains(t, err, "header Location is missing")
+	_, err = Capture("etag").FromHeader("Etag").Matches(`^W/`).capture(header, body)
+	assert.ErrorContains(t, err, "does not match")
+	_, err = Capture("etag").capture(header, body)
+	assert.ErrorContains(t, err, "no source")
+}
+
+func TestRequestExpand(t *testing.T) {
+	r := Request().
+		Path("/ipfs/{{cid}}/{{file}}", "bafy", Var("file")).
+		Query("filename", "{{file}}", Var("file")).
+		Header("If-None-Match", `W/"{{etag}}"`, Var("etag"))
+
+	expanded := r.expand(map[string]string{"file": "a.txt", "etag": "bafyetag"})
+	assert.Equal(t, "/ipfs/bafy/a.txt", expanded.Path_)
+	assert.Equal(t, "a.txt", expanded.Query_.Get("filename"))
+	assert.Equal(t, `W/"bafyetag"`, expanded.Headers_["If-None-Match"])
+
+	// The request is not modified, a test can be run again.
+	assert.Equal(t, "/ipfs/bafy/{{file}}", r.Path_)
+	assert.Equal(t, "{{file}}", r.Query_.Get("filename"))
+}
+
+func TestRunSteps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/file", http.StatusFound)
+		case "/never":
+			t.Error("a step using a missing variable was run")
+		case "/file":
+			w.Header().Set("Etag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write([]byte("hello"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GATEWAY_URL", server.URL)
+
+	run(t, SugarTests{
+		{
+			Name: "Redirect then revalidate",
+			Steps: []StepBuilder{
+				Step("GET the redirect").
+					Request(Request().Path("/redirect")).
+					Response(Expect().Status(302)).
+					Capture(Capture("location").FromHeader("Location")),
+				Step("GET the location").
+					Request(Request().Path("{{location}}", Var("location"))).
+					Response(Expect().Status(200).Body("hello")).
+					Capture(Capture("etag").FromHeader("Etag")),
+				Step("GET with If-None-Match").
+					Request(Request().
+						Path("{{location}}", Var("location")).
+						Header("If-None-Match", "{{etag}}", Var("etag"))).
+					Response(Expect().Status(304)),
+			},
+		},
+		{
+			Name: "Missing optional capture",
+			Steps: []StepBuilder{
+				Step("GET the file").
+					Request(Request().Path("/file")).
+					Capture(Capture("lastModified").FromHeader("Last-Modified").Optional()),
+				Step("Skipped").
+					Request(Request().Path("/never").Header("If-Modified-Since", "{{lastModified}}", Var("lastModified"))),
+				Step("Not skipped").
+					Request(Request().Path("/file")).
+					Response(Expect().Status(200)),
+			},
+		},
+	})
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"fmt"
+	"io"
 	"maps"
 	"net/http"
 	"net/url"
@@ -206,6 +207,10 @@ func (e ExpectBuilder) Body(body any) ExpectBuilder {
 		e.Body_ = body
 	case check.Check[[]byte]:
 		e.Body_ = body
+	case check.Check[io.Reader]:
+		e.Body_ = body
+	case check.Check[check.Response]:
+		e.Body_ = body
 	default:
 		panic("body must be string, []byte, or a regular check")
 	}
@@ -226,6 +231,8 @@ func (e ExpectBuilder) BodyWithHint(hint string, body any) ExpectBuilder {
 		panic("this check already has a hint")
 	case check.Check[string]:
 		e.Body_ = check.WithHint(hint, body)
+	case check.Check[io.Reader]:
+		e.Body_ = check.WithHint(hint, body)
 	default:
 		panic("body must be string, []byte, or a regular check")
 	}
@@ -242,7 +249,7 @@ func (e ExpectBuilder) Validate(t *testing.T, res *http.Response, localReport Re
 		t.Run(c.testName, func(t *testing.T) {
 			tooling.LogSpecs(t, c.specs...)
 			if !c.checkOutput.Success {
-				localReport(t, c.checkOutput.Reason)
+				localReport(t, checkError(c.checkOutput))
 			}
 		})
 	}
@@ -276,6 +283,10 @@ func (e ExpectBuilder) Clone() ExpectValidator {
 		clone.Body_ = body
 	case check.Check[[]byte]:
 		clone.Body_ = body
+	case check.Check[io.Reader]:
+		clone.Body_ = body
+	case check.Check[check.Response]:
+		clone.Body_ = body
 	default:
 		panic("body must be string, []byte, or a regular check")
 	}
@@ -354,6 +365,9 @@ func (e AnyOfExpectBuilder) Validate(t *testing.T, res *http.Response, localRepo
 							t.Logf("Test %s passed", c.testName)
 						} else {
 							t.Logf("Test %s failed with: %s", c.testName, c.checkOutput.Reason)
+							if c.checkOutput.Diff != nil {
+								t.Logf("Diff:\n%s", c.checkOutput.Diff)
+							}
 						}
 					}
 				}
@@ -410,6 +424,13 @@ func (h HeaderBuilder) Matches(value string, rest ...any) HeaderBuilder {
 	return h
 }
 
+// Satisfies checks the unique value of the header, e.g. with
+// check.IsMediaType.
+func (h HeaderBuilder) Satisfies(c check.Check[string]) HeaderBuilder {
+	h.Check_ = check.IsUniqAnd(c)
+	return h
+}
+
 func (h HeaderBuilder) Hint(hint string) HeaderBuilder {
 	h.Hint_ = hint
 	return h
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,6 +22,9 @@ type SugarTest struct {
 	Requests  []RequestBuilder
 	Response  ExpectValidator
 	Responses ExpectsBuilder
+	// Steps is a scenario: requests sent in order, each one can use the
+	// values captured from the responses of the previous steps.
+	Steps []StepBuilder
 }
 
 type SugarTests []SugarTest
@@ -65,41 +69,96 @@ func RunWithSpecs(
 		return
 	}
 
+	for _, transform := range Transforms {
+		tests = transform(t, tests)
+	}
+
 	run(t, tests)
 }
 
+// Transforms are applied to the tests of every RunWithSpecs call, e.g. to add
+// a HEAD request after every GET test, see the -head flag.
+var Transforms []func(t *testing.T, tests SugarTests) SugarTests
+
+var (
+	// Parallel is the number of SugarTests of a run that may be executed at
+	// the same time, see the -concurrency flag.
+	Parallel = 1
+	// TestTimeout bounds the duration of a SugarTest, all its requests
+	// included, see the -test-timeout flag.
+	TestTimeout = 2 * time.Minute
+	// RequestTimeout bounds the duration of a single request, reading the
+	// response body included, see the -request-timeout flag. Zero means no
+	// limit other than TestTimeout.
+	RequestTimeout time.Duration = 0
+)
+
 func run(t *testing.T, tests SugarTests) {
 	t.Helper()
 
-	for _, test := range tests {
-		timeout, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-		defer cancel()
+	// With Parallel > 1 the tests are started in order, up to Parallel at a
+	// time, and all of them complete before run returns: a test may depend on
+	// values captured by the tests of a previous run (e.g. an Etag).
+	sem := make(chan struct{}, Parallel)
+	var wg sync.WaitGroup
+	defer wg.Wait()
 
+	for _, test := range tests {
 		name := safeName(test.Name)
 
-		if len(test.Requests) > 0 {
+		if Parallel <= 1 {
 			t.Run(name, func(t *testing.T) {
-				tooling.LogSpecs(t, test.AllSpecs()...)
-				responses := make([]*http.Response, 0, len(test.Requests))
-
-				for _, req := range test.Requests {
-					_, res, localReport := runRequest(timeout, t, test, req)
-					if test.Response != nil {
-						test.Response.Validate(t, res, localReport)
-					}
-					responses = append(responses, res)
-				}
-
-				validateResponses(t, test.Responses, responses)
+				runTest(t, test)
 			})
-		} else {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		started := make(chan struct{})
+		start := sync.OnceFunc(func() { close(started) })
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer start() // the test was filtered out
 			t.Run(name, func(t *testing.T) {
-				tooling.LogSpecs(t, test.AllSpecs()...)
-				_, res, localReport := runRequest(timeout, t, test, test.Request)
-				if test.Response != nil {
-					test.Response.Validate(t, res, localReport)
-				}
+				start()
+				runTest(t, test)
 			})
+		}()
+		<-started
+	}
+}
+
+func runTest(t *testing.T, test SugarTest) {
+	timeout, cancel := context.WithTimeout(context.Background(), TestTimeout)
+	defer cancel()
+
+	tooling.LogSpecs(t, test.AllSpecs()...)
+	skipSpecs := validatorSpecs(test.Response)
+	for _, step := range test.Steps {
+		skipSpecs = append(skipSpecs, validatorSpecs(step.Response_)...)
+	}
+	tooling.SkipUnselected(t, skipSpecs...)
+
+	if len(test.Steps) > 0 {
+		runSteps(timeout, t, test)
+	} else if len(test.Requests) > 0 {
+		responses := make([]*http.Response, 0, len(test.Requests))
+
+		for _, req := range test.Requests {
+			_, res, localReport := runRequest(timeout, t, test, req)
+			if test.Response != nil {
+				test.Response.Validate(t, res, localReport)
+			}
+			responses = append(responses, res)
+		}
+
+		validateResponses(t, test.Responses, responses)
+	} else {
+		_, res, localReport := runRequest(timeout, t, test, test.Request)
+		if test.Response != nil {
+			test.Response.Validate(t, res, localReport)
 		}
 	}
 }
@@ -116,3 +175,25 @@ func safeName(s string) string {
 	// Join the parts back together with spaces
 	return strings.Join(parts, " ")
 }
+
+// validatorSpecs returns the specs of the expectations of a validator, and of
+// their headers.
+func validatorSpecs(v ExpectValidator) []string {
+	var specs []string
+	switch v := v.(type) {
+	case ExpectBuilder:
+		specs = append(specs, v.Specs_...)
+		for _, header := range v.Headers_ {
+			specs = append(specs, header.Specs_...)
+		}
+	case AllOfExpectBuilder:
+		for _, e := range v.Expect_ {
+			specs = append(specs, validatorSpecs(e)...)
+		}
+	case AnyOfExpectBuilder:
+		for _, e := range v.Expect_ {
+			specs = append(specs, validatorSpecs(e)...)
+		}
+	}
+	return specs
+}
@@ -0,0 +1,45 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunParallel(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			m := maxInflight.Load()
+			if n <= m || maxInflight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+	t.Setenv("GATEWAY_URL", server.URL)
+
+	defer func(p int) { Parallel = p }(Parallel)
+	Parallel = 2
+
+	var tests SugarTests
+	for _, name := range []string{"a", "b", "c", "d"} {
+		tests = append(tests, SugarTest{
+			Name:     name,
+			Request:  Request().Path("/" + name),
+			Response: Expect().Status(200),
+		})
+	}
+	run(t, tests)
+
+	// run waits for its tests.
+	assert.Equal(t, int32(0), inflight.Load())
+	assert.Equal(t, int32(2), maxInflight.Load())
+}
@@ -84,7 +84,13 @@ func validateResponse(
 		outputs = append(outputs, testCheckOutput{testName: testName, checkOutput: output, specs: header.Specs_})
 	}
 
-	if expected.Body_ != nil {
+	if c, ok := expected.Body_.(check.Check[io.Reader]); ok {
+		// Streaming checks read the body as it is received, it is not buffered.
+		defer res.Body.Close()
+		output := c.Check(res.Body)
+		res.Body = http.NoBody
+		outputs = append(outputs, testCheckOutput{testName: "Body", checkOutput: bodyOutput(output)})
+	} else if expected.Body_ != nil {
 		defer res.Body.Close()
 		resBody, err := io.ReadAll(res.Body)
 		if err != nil {
@@ -100,6 +106,8 @@ func validateResponse(
 			output = v.Check(string(resBody))
 		case check.Check[[]byte]:
 			output = v.Check(resBody)
+		case check.Check[check.Response]:
+			output = v.Check(check.Response{Header: res.Header, Body: resBody})
 		case string:
 			output = check.IsEqual(v).Check(string(resBody))
 		case []byte:
@@ -111,19 +119,22 @@ func validateResponse(
 			}
 		}
 
-		if !output.Success {
-			if output.Hint == "" {
-				output.Reason = fmt.Sprintf("Body %s", output.Reason)
-			} else {
-				output.Reason = fmt.Sprintf("Body %s (%s)", output.Reason, output.Hint)
-			}
-		}
-
-		outputs = append(outputs, testCheckOutput{testName: "Body", checkOutput: output})
+		outputs = append(outputs, testCheckOutput{testName: "Body", checkOutput: bodyOutput(output)})
 	}
 	return outputs
 }
 
+func bodyOutput(output check.CheckOutput) check.CheckOutput {
+	if !output.Success {
+		if output.Hint == "" {
+			output.Reason = fmt.Sprintf("Body %s", output.Reason)
+		} else {
+			output.Reason = fmt.Sprintf("Body %s (%s)", output.Reason, output.Hint)
+		}
+	}
+	return output
+}
+
 func readPayload(res *http.Response) ([]byte, error) {
 	defer res.Body.Close()
 	return io.ReadAll(res.Body)
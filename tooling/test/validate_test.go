@@ -2,6 +2,7 @@ package test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"io"
 	"net/http"
 	"testing"
@@ -51,3 +52,55 @@ func TestValidateResponseIsJSONEqualOnHTMLBodyDoesNotPanic(t *testing.T) {
 		assert.Contains(t, bodyOutput.checkOutput.Reason, "not valid JSON")
 	}
 }
+
+func TestValidateResponseStreamingBody(t *testing.T) {
+	content := bytes.Repeat([]byte("large file"), 1<<16)
+	digest := sha256.Sum256(content)
+
+	validate := func(body check.Check[io.Reader]) check.CheckOutput {
+		res := &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewReader(content)),
+		}
+		outputs := validateResponse(t, Expect().Body(body), res)
+		assert.Len(t, outputs, 1)
+		return outputs[0].checkOutput
+	}
+
+	assert.True(t, validate(check.HashEquals(sha256.New, digest[:])).Success)
+
+	output := validate(check.WithHint[io.Reader]("the file is corrupted", check.HashEquals(sha256.New, []byte("nope"))))
+	assert.False(t, output.Success)
+	assert.Contains(t, output.Reason, "Body expected a digest of")
+	assert.Contains(t, output.Reason, "(the file is corrupted)")
+}
+
+func TestValidateResponseBodyDiff(t *testing.T) {
+	expected := "<ul>\n<li>a.txt</li>\n<li>b.txt</li>\n</ul>\n"
+	res := &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader([]byte("<ul>\n<li>a.txt</li>\n<li>c.txt</li>\n</ul>\n"))),
+	}
+
+	outputs := validateResponse(t, Expect().Body(expected), res)
+	assert.Len(t, outputs, 1)
+
+	output := outputs[0].checkOutput
+	assert.False(t, output.Success)
+	assert.Equal(t, "Body differs from the expected value, see the diff", output.Reason)
+	assert.NotNil(t, output.Diff)
+	assert.Contains(t, output.Diff.String(), "-<li>b.txt</li>\n+<li>c.txt</li>")
+}
+
+func TestValidateResponseBodyWithHeaders(t *testing.T) {
+	body := "--b\r\nContent-Type: text/plain\r\nContent-Range: bytes 0-4/11\r\n\r\nhello\r\n--b--\r\n"
+	res := &http.Response{
+		StatusCode: 206,
+		Header:     http.Header{"Content-Type": []string{"multipart/byteranges; boundary=b"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}
+
+	outputs := validateResponse(t, Expect().Body(check.IsMultipartByteranges().WithPart("bytes 0-4/11", []byte("hello"))), res)
+	assert.Len(t, outputs, 1)
+	assert.True(t, outputs[0].checkOutput.Success, outputs[0].checkOutput.Reason)
+}
@@ -0,0 +1,54 @@
+package test2json
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConverter(t *testing.T) {
+	input := "\x16=== RUN   TestFoo\n" +
+		"\x16=== RUN   TestFoo/bar\n" +
+		"    foo.go:1: --- META: {\"group\":\"UnixFS\"}\n" +
+		"\x16--- FAIL: TestFoo/bar (0.01s)\n" +
+		"\x16=== NAME  TestFoo\n" +
+		"\x16--- FAIL: TestFoo (0.02s)\n" +
+		"\x16=== NAME  \n" +
+		"\x16FAIL\n"
+
+	var buf bytes.Buffer
+	c := NewConverter(&buf, "Gateway Tests", 0)
+	c.Write([]byte(input))
+	c.Close()
+
+	type event struct {
+		Action  string
+		Package string
+		Test    string
+		Output  string
+	}
+
+	var events []event
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "Gateway Tests", e.Package)
+		if e.Action != "output" {
+			events = append(events, e)
+		}
+	}
+
+	assert.Equal(t, []event{
+		{Action: "start", Package: "Gateway Tests"},
+		{Action: "run", Package: "Gateway Tests", Test: "TestFoo"},
+		{Action: "run", Package: "Gateway Tests", Test: "TestFoo/bar"},
+		{Action: "fail", Package: "Gateway Tests", Test: "TestFoo/bar"},
+		{Action: "fail", Package: "Gateway Tests", Test: "TestFoo"},
+		{Action: "fail", Package: "Gateway Tests"},
+	}, events)
+}